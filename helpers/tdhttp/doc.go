@@ -39,6 +39,32 @@
 //
 // See the full example below.
 //
+// The same assertions can be run against a live server, as an
+// end-to-end test suite would do, using [NewTestAPIClient]:
+//
+//	ta := tdhttp.NewTestAPIClient(t, "https://localhost:8443", client)
+//
+// Session state, as cookies, default headers or authentication, can
+// be kept between requests using [TestAPI.WithCookieJar],
+// [TestAPI.WithDefaultHeader], [TestAPI.WithBearerToken],
+// [TestAPI.WithBasicAuth] and [TestAPI.WithBaseQuery].
+//
+// # Mocking a server
+//
+// HTTP clients can be tested too, using [MockServer]. It is an
+// [http.Handler] as well as an [http.RoundTripper] replying to requests
+// matching expectations with canned responses:
+//
+//	mock := tdhttp.NewMockServer(t)
+//	mock.Expect(td.Struct(tdhttp.MockRequest{Method: "POST", Path: "/users"}, nil),
+//	  td.JSON(`{"name": "Bob"}`)).
+//	  Respond(http.StatusCreated, map[string]any{"id": 42})
+//
+//	client := NewMyClient(mock.Client())
+//
+// Unmet expectations and unexpected requests are reported at the end
+// of the test.
+//
 // # Cmp…Response functions
 //
 // Historically, it was the only way to test HTTP APIs using
@@ -0,0 +1,231 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"mime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+)
+
+// decodableContentEncoding returns true if contentEncoding, the
+// value of the Content-Encoding header, only lists supported
+// encodings, at least one of them being gzip or deflate.
+func decodableContentEncoding(contentEncoding string) bool {
+	decodable := false
+	for _, encoding := range strings.Split(contentEncoding, ",") {
+		switch strings.ToLower(strings.TrimSpace(encoding)) {
+		case "", "identity":
+		case "gzip", "x-gzip", "deflate":
+			decodable = true
+		default:
+			return false
+		}
+	}
+	return decodable
+}
+
+// contentDecoder returns a reader decoding r according to
+// contentEncoding, the value of the Content-Encoding header, in which
+// encodings are listed in the order they have been applied. It is the
+// caller responsibility to check contentEncoding is decodable, see
+// decodableContentEncoding.
+func contentDecoder(r io.Reader, contentEncoding string) (io.Reader, error) {
+	encodings := strings.Split(contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var err error
+		switch strings.ToLower(strings.TrimSpace(encodings[i])) {
+		case "gzip", "x-gzip":
+			r, err = gzip.NewReader(r)
+		case "deflate":
+			// deflate is supposed to be zlib wrapped, but some
+			// servers send raw deflate data
+			br := bufio.NewReader(r)
+			if isZlibHeader(br) {
+				r, err = zlib.NewReader(br)
+			} else {
+				r = flate.NewReader(br)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// isZlibHeader returns true if br starts with a zlib header without
+// preset dictionary.
+func isZlibHeader(br *bufio.Reader) bool {
+	h, _ := br.Peek(2)
+	return len(h) == 2 &&
+		h[0]&0x0f == 8 && // deflate compression method
+		h[1]&0x20 == 0 && // no preset dictionary
+		(uint16(h[0])<<8|uint16(h[1]))%31 == 0
+}
+
+// decodeContentEncoding decodes body according to contentEncoding,
+// the value of the Content-Encoding header. If an encoding is not
+// supported, body is returned as is.
+func decodeContentEncoding(body []byte, contentEncoding string) ([]byte, error) {
+	if !decodableContentEncoding(contentEncoding) {
+		return body, nil
+	}
+	r, err := contentDecoder(bytes.NewReader(body), contentEncoding)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+// decodeResponseBody transparently decodes the last response body
+// according to its Content-Encoding header, if gzip and/or
+// deflate. The Content-Encoding header is kept as is, so it can still
+// be checked using CmpContentEncoding or CmpHeader, but as net/http
+// does for the responses it transparently decompresses, the
+// Content-Length header is removed, as it does not match the decoded
+// body anymore.
+func (t *TestAPI) decodeResponseBody() {
+	contentEncoding := t.response.Header().Get("Content-Encoding")
+	if !decodableContentEncoding(contentEncoding) || t.response.Body.Len() == 0 {
+		return
+	}
+
+	body, err := decodeContentEncoding(t.response.Body.Bytes(), contentEncoding)
+	if err != nil {
+		t.t.Helper()
+		t.bodyDecodingFailed(contentEncoding, err)
+		return
+	}
+	t.response.Body = bytes.NewBuffer(body)
+	t.response.Header().Del("Content-Length")
+}
+
+// bodyDecodingFailed reports the failure of the decoding of the last
+// response body according to contentEncoding.
+func (t *TestAPI) bodyDecodingFailed(contentEncoding string, err error) {
+	t.t.Helper()
+	t.t.RootName("Response.Body").
+		Code(false, func(bool) error {
+			return &ctxerr.Error{
+				Message: "%% cannot be decoded",
+				Summary: ctxerr.NewSummary(contentEncoding + ": " + err.Error()),
+			}
+		},
+			t.name+"body decoding")
+	t.bodyFailed = true
+}
+
+// charsetToUTF8 transcodes body to UTF-8 according to the charset
+// parameter of contentType. Only ISO-8859-1 and UTF-16 charsets are
+// transcoded, body is returned as is for other ones.
+func charsetToUTF8(body []byte, contentType string) []byte {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return body
+	}
+
+	switch strings.ToLower(params["charset"]) {
+	case "iso-8859-1", "iso8859-1", "latin1", "l1":
+		var buf bytes.Buffer
+		buf.Grow(len(body))
+		for _, b := range body {
+			buf.WriteRune(rune(b))
+		}
+		return buf.Bytes()
+
+	case "utf-16":
+		// RFC 2781: big endian unless a BOM says otherwise
+		if len(body) >= 2 && body[0] == 0xff && body[1] == 0xfe {
+			return utf16ToUTF8(body[2:], false)
+		}
+		if len(body) >= 2 && body[0] == 0xfe && body[1] == 0xff {
+			body = body[2:]
+		}
+		return utf16ToUTF8(body, true)
+
+	case "utf-16be":
+		return utf16ToUTF8(body, true)
+
+	case "utf-16le":
+		return utf16ToUTF8(body, false)
+	}
+	return body
+}
+
+// utf16ToUTF8 transcodes body, UTF-16 encoded, to UTF-8. A leading
+// BOM is dropped, and a trailing odd byte becomes U+FFFD.
+func utf16ToUTF8(body []byte, bigEndian bool) []byte {
+	units := make([]uint16, 0, len(body)/2)
+	for i := 0; i+1 < len(body); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(body[i])<<8|uint16(body[i+1]))
+		} else {
+			units = append(units, uint16(body[i+1])<<8|uint16(body[i]))
+		}
+	}
+	if len(units) > 0 && units[0] == 0xfeff {
+		units = units[1:]
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(len(units))
+	for _, r := range utf16.Decode(units) {
+		buf.WriteRune(r)
+	}
+	if len(body)%2 != 0 {
+		buf.WriteRune(utf8.RuneError)
+	}
+	return buf.Bytes()
+}
+
+// CmpContentEncoding tests the Content-Encoding header of the last
+// request response against expected. expected can be a string or a
+// [td.TestDeep] operator. A missing header is seen as "".
+//
+//	ta.Get("/data.json", "Accept-Encoding", "gzip").
+//	  CmpStatus(http.StatusOK).
+//	  CmpContentEncoding("gzip").
+//	  CmpJSONBody(td.JSON(`{"name": "Bob"}`))
+//
+// Note that a body encoded using gzip and/or deflate is transparently
+// decoded as soon as the response is received, so all body checks
+// and dumps apply to the decoded body.
+//
+// It fails if no request has been sent yet.
+func (t *TestAPI) CmpContentEncoding(expected any) *TestAPI {
+	defer t.t.AnchorsPersistTemporarily()()
+
+	t.t.Helper()
+
+	if !t.checkRequestSent() {
+		t.headerFailed = true
+		return t
+	}
+
+	if !t.t.RootName(`Response.Header["Content-Encoding"]`).
+		Cmp(t.response.Header().Get("Content-Encoding"), expected,
+			t.name+"content encoding should match") {
+		t.headerFailed = true
+
+		if t.autoDumpResponse {
+			t.dumpResponse()
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,192 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/helpers/tdhttp"
+	"github.com/maxatome/go-testdeep/internal/test"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func compress(newWriter func(io.Writer) io.WriteCloser, data string) []byte {
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	io.WriteString(w, data) //nolint: errcheck
+	w.Close()               //nolint: errcheck
+	return buf.Bytes()
+}
+
+func encodingServer() *http.ServeMux {
+	const body = `{"name": "Bob"}`
+
+	mux := http.NewServeMux()
+	handle := func(path, contentType, contentEncoding string, body []byte) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			if contentEncoding != "" {
+				w.Header().Set("Content-Encoding", contentEncoding)
+			}
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Write(body) //nolint: errcheck
+		})
+	}
+
+	gz := compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }, body)
+	zl := compress(func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }, body)
+	fl := compress(func(w io.Writer) io.WriteCloser {
+		fw, _ := flate.NewWriter(w, flate.DefaultCompression)
+		return fw
+	}, body)
+
+	handle("/gzip", "application/json", "gzip", gz)
+	handle("/deflate", "application/json", "deflate", zl)
+	handle("/raw-deflate", "application/json", "deflate", fl)
+	handle("/deflate-gzip", "application/json", "deflate, gzip",
+		compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }, string(zl)))
+	handle("/identity", "application/json", "identity", []byte(body))
+	handle("/br", "application/json", "br", []byte("\x0b\x02\x80"))
+	handle("/corrupted", "application/json", "gzip", []byte("not gzip"))
+
+	handle("/latin1", "text/plain; charset=ISO-8859-1", "", []byte("Caf\xe9"))
+	handle("/utf16", "text/plain; charset=utf-16", "", []byte("\x00C\x00a\x00f\x00\xe9"))
+	handle("/utf16-bom", "text/plain; charset=utf-16", "", []byte("\xff\xfeC\x00a\x00f\x00\xe9\x00"))
+	handle("/utf16le", "text/plain; charset=UTF-16LE", "", []byte("C\x00a\x00f\x00\xe9\x00"))
+	handle("/utf16be", "text/plain; charset=utf-16be", "", []byte("\x00C\x00a\x00f\x00\xe9"))
+	handle("/gzip-latin1", "text/plain; charset=latin1", "gzip",
+		compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }, "Caf\xe9"))
+
+	return mux
+}
+
+func TestContentEncoding(t *testing.T) {
+	mux := encodingServer()
+
+	check := func(t *testing.T, ta *tdhttp.TestAPI) {
+		t.Helper()
+
+		for path, encoding := range map[string]string{
+			"/gzip":         "gzip",
+			"/deflate":      "deflate",
+			"/raw-deflate":  "deflate",
+			"/deflate-gzip": "deflate, gzip",
+			"/identity":     "identity",
+		} {
+			ta.Get(path, "Accept-Encoding", "gzip, deflate").
+				CmpStatus(http.StatusOK).
+				CmpContentEncoding(encoding).
+				CmpJSONBody(td.JSON(`{"name": "Bob"}`))
+			td.CmpFalse(t, ta.Failed(), path)
+		}
+
+		// Content-Length of the encoded body is removed
+		ta.Get("/gzip", "Accept-Encoding", "gzip").
+			CmpHeader(td.Not(td.ContainsKey("Content-Length")))
+		td.CmpFalse(t, ta.Failed())
+
+		// Unsupported encoding, body kept as is
+		ta.Get("/br", "Accept-Encoding", "br").
+			CmpContentEncoding("br").
+			CmpHeader(td.ContainsKey("Content-Length")).
+			CmpBody([]byte("\x0b\x02\x80"))
+		td.CmpFalse(t, ta.Failed())
+
+		ta.Get("/latin1").
+			CmpContentEncoding("").
+			CmpBody("Café")
+		td.CmpFalse(t, ta.Failed())
+	}
+
+	t.Run("Handler", func(t *testing.T) {
+		check(t, tdhttp.NewTestAPI(t, mux))
+	})
+
+	t.Run("Client", func(t *testing.T) {
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		check(t, tdhttp.NewTestAPIClient(t, srv.URL, nil))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tb := test.NewTestingTB("test")
+		ta := tdhttp.NewTestAPI(tb, mux)
+
+		td.CmpTrue(t, ta.CmpContentEncoding("gzip").Failed(), "no request sent")
+
+		td.CmpTrue(t, ta.Get("/gzip").CmpContentEncoding("deflate").Failed(),
+			"bad encoding")
+		td.Cmp(t, tb.LastMessage(),
+			td.Contains(`Response.Header["Content-Encoding"]: values differ`))
+
+		td.CmpTrue(t, ta.Get("/corrupted").Failed(), "corrupted body")
+		td.Cmp(t, tb.LastMessage(), td.All(
+			td.Contains("Response.Body cannot be decoded"),
+			td.Contains("gzip: "),
+		))
+		ta.CmpBody("not gzip") // body kept as is
+	})
+}
+
+func TestCharset(t *testing.T) {
+	ta := tdhttp.NewTestAPI(t, encodingServer())
+
+	for _, path := range []string{
+		"/latin1", "/utf16", "/utf16-bom", "/utf16le", "/utf16be", "/gzip-latin1",
+	} {
+		ta.Get(path).
+			CmpStatus(http.StatusOK).
+			CmpBody("Café").
+			CmpBody(td.HasSuffix("fé"))
+		td.CmpFalse(t, ta.Failed(), path)
+	}
+
+	// []byte are not transcoded
+	ta.Get("/latin1").
+		CmpBody([]byte("Caf\xe9"))
+	td.CmpFalse(t, ta.Failed())
+
+	t.Run("Dump", func(t *testing.T) {
+		tb := test.NewTestingTB("test")
+		ta := tdhttp.NewTestAPI(tb, encodingServer())
+
+		ta.Get("/gzip").
+			CmpStatus(http.StatusNotFound).
+			OrDumpResponse()
+		td.Cmp(t, tb.LastMessage(), td.All(
+			td.HasPrefix("Received response:\n"),
+			td.Contains(`{"name": "Bob"}`),
+		))
+
+		ta.Get("/gzip-latin1").
+			CmpStatus(http.StatusNotFound).
+			OrDumpResponse()
+		td.Cmp(t, tb.LastMessage(), td.All(
+			td.HasPrefix("Received response:\n"),
+			td.Contains("Content-Length: 5\n"),
+			td.HasSuffix("\n\nCafé`"),
+		))
+
+		ta.Get("/latin1").
+			CmpStatus(http.StatusNotFound).
+			OrDumpResponse()
+		td.Cmp(t, tb.LastMessage(), td.All(
+			td.HasPrefix("Received response:\n"),
+			td.Contains("Content-Length: 5\n"),
+			td.HasSuffix("\n\nCafé`"),
+		))
+	})
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/maxatome/go-testdeep/internal/color"
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// fieldsPathToJSONPointer converts a fields-path as "data.items[0].id"
+// to the corresponding JSON pointer "/data/items/0/id".
+func fieldsPathToJSONPointer(path string) string {
+	var b bytes.Buffer
+	for path != "" {
+		var token string
+		switch path[0] {
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				token, path = path[1:], ""
+			} else {
+				token, path = path[1:end], path[end+1:]
+			}
+
+		case '.':
+			path = path[1:]
+			continue
+
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			token, path = path[:end], path[end:]
+		}
+		b.WriteByte('/')
+		b.WriteString(jsonPointerEscaper.Replace(token))
+	}
+	return b.String()
+}
+
+// Extract copies in target the value found at path in the last
+// request response body, once JSON unmarshaled. It allows to reuse
+// a value returned by the API, typically an ID, in the following
+// requests:
+//
+//	var id int64
+//	ta.PostJSON("/items", map[string]string{"name": "foo"}).
+//	  CmpStatus(http.StatusCreated).
+//	  Extract("/id", &id)
+//
+//	ta.Get(fmt.Sprintf("/items/%d", id)).
+//	  CmpStatus(http.StatusOK)
+//
+// path is either a JSON pointer, as [RFC 6901] specifies it, or a
+// fields-path à la [td.Smuggle] as "data.items[0].id", that is
+// equivalent to "/data/items/0/id" JSON pointer.
+//
+// target must be a non-nil pointer. The extracted value is converted
+// to the type target points to, as [td.JSONPointer] operator does. So
+// the previous example is equivalent to:
+//
+//	ta.PostJSON("/items", map[string]string{"name": "foo"}).
+//	  CmpStatus(http.StatusCreated).
+//	  CmpJSONBody(td.JSONPointer("/id", td.Catch(&id, td.Ignore())))
+//
+// except that only the extracted value matters.
+//
+// It fails if no request has been sent yet, if the body cannot be
+// unmarshaled, if path does not exist or if the value cannot be
+// converted to target type. In these cases, target is left
+// untouched.
+//
+// [RFC 6901]: https://tools.ietf.org/html/rfc6901
+func (t *TestAPI) Extract(path string, target any) *TestAPI {
+	t.t.Helper()
+
+	if !t.checkRequestSent() {
+		t.bodyFailed = true
+		return t
+	}
+
+	pointer := path
+	if path != "" && path[0] != '/' {
+		pointer = fieldsPathToJSONPointer(path)
+	}
+
+	tt := t.t.RootName("Response.Body")
+
+	var body any
+	if !tt.RootName("unmarshal(Response.Body)").
+		CmpNoError(json.Unmarshal(t.response.Body.Bytes(), &body), t.name+"body unmarshaling") {
+		t.bodyFailed = true
+		t.dumpResponse()
+		return t
+	}
+
+	if !tt.Cmp(body, td.JSONPointer(pointer, td.Catch(target, td.Ignore())),
+		t.name+"extract "+path) {
+		t.bodyFailed = true
+		if t.autoDumpResponse {
+			t.dumpResponse()
+		}
+	}
+	return t
+}
+
+// ExtractHeader copies in target the value of the name header of the
+// last request response:
+//
+//	var location string
+//	ta.PostJSON("/items", map[string]string{"name": "foo"}).
+//	  CmpStatus(http.StatusCreated).
+//	  ExtractHeader("Location", &location)
+//
+//	ta.Get(location).
+//	  CmpStatus(http.StatusOK)
+//
+// target must be a non-nil pointer on:
+//   - a string, receiving the first value of the header;
+//   - a []string, receiving all the values of the header;
+//   - a type implementing [encoding.TextUnmarshaler], as
+//     [time.Time], receiving the first value of the header;
+//   - any other type, the first value of the header being then JSON
+//     unmarshaled in it, allowing numbers and booleans.
+//
+// It fails if no request has been sent yet, if the header is missing
+// or if its value cannot be converted to target type.
+func (t *TestAPI) ExtractHeader(name string, target any) *TestAPI {
+	t.t.Helper()
+
+	vtarget := reflect.ValueOf(target)
+	if vtarget.Kind() != reflect.Ptr || vtarget.IsNil() {
+		t.t.Fatal(color.BadUsage("ExtractHeader(NAME, NON_NIL_PTR)", target, 2, true))
+	}
+
+	if !t.checkRequestSent() {
+		t.headerFailed = true
+		return t
+	}
+
+	name = http.CanonicalHeaderKey(name)
+	ok := t.t.RootName("Response.Header").
+		Code(t.response.Header()[name],
+			func(values []string) error {
+				if len(values) == 0 {
+					return &ctxerr.Error{
+						Message: "%% key not found",
+						Summary: ctxerr.NewSummary(name),
+					}
+				}
+
+				var err error
+				switch ptr := target.(type) {
+				case *string:
+					*ptr = values[0]
+				case *[]string:
+					*ptr = append([]string(nil), values...)
+				case encoding.TextUnmarshaler:
+					err = ptr.UnmarshalText([]byte(values[0]))
+				default:
+					// Unmarshal in a new value to leave target untouched on error
+					v := reflect.New(vtarget.Type().Elem())
+					if err = json.Unmarshal([]byte(values[0]), v.Interface()); err == nil {
+						vtarget.Elem().Set(v.Elem())
+					}
+				}
+				if err != nil {
+					return &ctxerr.Error{
+						Message: "cannot extract %%[" + name + "]",
+						Summary: ctxerr.NewSummary(err.Error()),
+					}
+				}
+				return nil
+			},
+			t.name+"extract header "+name)
+	if !ok {
+		t.headerFailed = true
+		if t.autoDumpResponse {
+			t.dumpResponse()
+		}
+	}
+	return t
+}
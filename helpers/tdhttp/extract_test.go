@@ -0,0 +1,128 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/helpers/tdhttp"
+	"github.com/maxatome/go-testdeep/helpers/tdutil"
+	"github.com/maxatome/go-testdeep/internal/test"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func extractServer() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/item", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/item/42")
+		w.Header().Set("X-Id", "42")
+		w.Header().Add("X-Tag", "a")
+		w.Header().Add("X-Tag", "b")
+		w.Header().Set("Last-Modified", "2022-10-01T12:13:14Z")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"data":{"id":42,"items":[{"name":"foo"},{"name":"bar"}],"a/b":true}}`)) //nolint: errcheck
+	})
+
+	mux.HandleFunc("/text", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("not JSON")) //nolint: errcheck
+	})
+
+	return mux
+}
+
+func TestExtract(t *testing.T) {
+	mux := extractServer()
+
+	t.Run("OK", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(t, mux)
+		ta.Post("/item", nil).CmpStatus(http.StatusCreated)
+
+		var id int64
+		var name string
+		var slash bool
+		var items []map[string]string
+		td.CmpFalse(t, ta.
+			Extract("/data/id", &id).
+			Extract("data.items[1].name", &name).
+			Extract("/data/a~1b", &slash).
+			Extract("data.items", &items).
+			Failed())
+		td.Cmp(t, id, int64(42))
+		td.Cmp(t, name, "bar")
+		td.CmpTrue(t, slash)
+		td.Cmp(t, items, []map[string]string{{"name": "foo"}, {"name": "bar"}})
+
+		var all map[string]any
+		td.CmpFalse(t, ta.Extract("", &all).Failed())
+		td.Cmp(t, all, td.ContainsKey("data"))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(tdutil.NewT("test"), mux)
+
+		var id int64
+		td.CmpTrue(t, ta.Extract("/id", &id).Failed(), "no request sent")
+
+		ta.Post("/item", nil)
+		td.CmpTrue(t, ta.Extract("/data/unknown", &id).Failed())
+		td.CmpTrue(t, ta.Extract("data.items[12]", &id).Failed())
+		td.CmpTrue(t, ta.Post("/item", nil).Extract("data.items", &id).Failed())
+		td.CmpTrue(t, ta.Post("/item", nil).Extract("/data/id", id).Failed())
+		td.Cmp(t, id, int64(0))
+
+		td.CmpTrue(t, ta.Get("/text").Extract("/id", &id).Failed())
+	})
+}
+
+func TestExtractHeader(t *testing.T) {
+	mux := extractServer()
+
+	t.Run("OK", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(t, mux)
+		ta.Post("/item", nil).CmpStatus(http.StatusCreated)
+
+		var (
+			location string
+			id       int
+			tags     []string
+			modified time.Time
+		)
+		td.CmpFalse(t, ta.
+			ExtractHeader("Location", &location).
+			ExtractHeader("x-id", &id).
+			ExtractHeader("X-Tag", &tags).
+			ExtractHeader("Last-Modified", &modified).
+			Failed())
+		td.Cmp(t, location, "/item/42")
+		td.Cmp(t, id, 42)
+		td.Cmp(t, tags, []string{"a", "b"})
+		td.Cmp(t, modified, time.Date(2022, time.October, 1, 12, 13, 14, 0, time.UTC))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(tdutil.NewT("test"), mux)
+
+		var id int
+		td.CmpTrue(t, ta.ExtractHeader("X-Id", &id).Failed(), "no request sent")
+
+		td.CmpTrue(t, ta.Post("/item", nil).ExtractHeader("X-Unknown", &id).Failed())
+		td.CmpTrue(t, ta.Post("/item", nil).ExtractHeader("Location", &id).Failed())
+		td.Cmp(t, id, 0)
+
+		var modified time.Time
+		td.CmpTrue(t, ta.Post("/item", nil).ExtractHeader("X-Id", &modified).Failed())
+
+		tb := test.NewTestingTB("test")
+		ta = tdhttp.NewTestAPI(tb, mux)
+		td.CmpContains(t, tb.CatchFatal(func() { ta.ExtractHeader("X-Id", id) }),
+			"usage: ExtractHeader(NAME, NON_NIL_PTR), but received int as 2nd parameter")
+	})
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/maxatome/go-testdeep/internal/color"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// MultipartResponsePart is a part of a multipart response body, as
+// parsed by [TestAPI.CmpMultipartBody].
+type MultipartResponsePart struct {
+	Name     string      // is "name" in Content-Disposition, if any.
+	Filename string      // is "filename" in Content-Disposition, if any.
+	Header   http.Header // is the header of the part.
+	Content  string      // is the body section of the part.
+}
+
+// parseMultipart parses body as a multipart body whose boundary is
+// found in contentType.
+func parseMultipart(body []byte, contentType string) ([]MultipartResponsePart, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("Content-Type %q: %s", contentType, err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return nil, fmt.Errorf("Content-Type %q is not multipart with a boundary", contentType)
+	}
+
+	var parts []MultipartResponsePart
+	r := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		part := MultipartResponsePart{Header: http.Header(p.Header)}
+		if _, dparams, err := mime.ParseMediaType(p.Header.Get("Content-Disposition")); err == nil {
+			part.Name = dparams["name"]
+			part.Filename = dparams["filename"]
+		}
+
+		content, err := ioutil.ReadAll(p)
+		if err != nil {
+			return nil, err
+		}
+		part.Content = string(content)
+
+		parts = append(parts, part)
+	}
+}
+
+// CmpMultipartBody tests that the last request response body is a
+// multipart one (as multipart/form-data or multipart/mixed) whose
+// parts match expectedParts, in order and with no extra parts.
+//
+// Each item of expectedParts is compared against the corresponding
+// [MultipartResponsePart] and can be a [MultipartResponsePart] or a
+// [td.TestDeep] operator. As a special case, a
+// [MultipartResponsePart] with a nil Header field does not check the
+// header of the received part:
+//
+//	ta.Get("/export").
+//	  CmpStatus(http.StatusOK).
+//	  CmpMultipartBody(
+//	    tdhttp.MultipartResponsePart{Name: "meta", Content: `{"count":2}`},
+//	    td.Struct(
+//	      tdhttp.MultipartResponsePart{Name: "file", Filename: "users.csv"},
+//	      td.StructFields{
+//	        "Header":  td.SuperMapOf(http.Header{"Content-Type": {"text/csv"}}, nil),
+//	        "Content": td.HasPrefix("id,name\n"),
+//	      }),
+//	  )
+//
+// The boundary is read from the Content-Type header of the
+// response. Parts encoded using quoted-printable are transparently
+// decoded.
+//
+// It fails if no request has been sent yet or if the body cannot be
+// parsed as multipart.
+func (t *TestAPI) CmpMultipartBody(expectedParts ...any) *TestAPI {
+	t.t.Helper()
+
+	entries := make(td.ArrayEntries, len(expectedParts))
+	for i, expected := range expectedParts {
+		if part, ok := expected.(MultipartResponsePart); ok && part.Header == nil {
+			expected = td.SStruct(part, td.StructFields{"Header": td.Ignore()})
+		}
+		entries[i] = expected
+	}
+
+	return t.cmpMarshaledBody(
+		false, // a multipart body always contains at least the final boundary
+		func(body []byte, target any) error {
+			parts, err := parseMultipart(body, t.response.Header().Get("Content-Type"))
+			if err != nil {
+				return err
+			}
+			*target.(*[]MultipartResponsePart) = parts
+			return nil
+		},
+		td.Slice([]MultipartResponsePart{}, entries))
+}
+
+// CmpFormBody tests that the last request response body is
+// "application/x-www-form-urlencoded" encoded and that it matches
+// expected. expected can be a [url.Values], a [Q] or a [td.TestDeep]
+// operator. A [Q] is first converted to [url.Values], so the same
+// semantic as for query parameters applies:
+//
+//	ta.PostForm("/oauth/token", url.Values{"grant_type": {"client_credentials"}}).
+//	  CmpStatus(http.StatusOK).
+//	  CmpFormBody(td.SuperMapOf(
+//	    url.Values{"token_type": {"bearer"}},
+//	    td.MapEntries{"access_token": td.Len(1)},
+//	  ))
+//
+//	ta.Get("/settings").
+//	  CmpStatus(http.StatusOK).
+//	  CmpFormBody(tdhttp.Q{"page": 2, "ids": []int{1, 2}, "dryrun": true})
+//
+// It fails if no request has been sent yet or if the body cannot be
+// parsed.
+func (t *TestAPI) CmpFormBody(expected any) *TestAPI {
+	t.t.Helper()
+
+	if q, ok := expected.(Q); ok {
+		values := url.Values{}
+		if err := q.AddTo(values); err != nil {
+			t.t.Fatal(color.Bad("CmpFormBody: %s", err))
+		}
+		expected = values
+	}
+
+	return t.cmpMarshaledBody(
+		true, // accept empty body, as an empty form
+		func(body []byte, target any) error {
+			values, err := url.ParseQuery(string(body))
+			if err != nil {
+				return err
+			}
+			switch target := target.(type) {
+			case *url.Values:
+				*target = values
+			case *any:
+				*target = values
+			default:
+				// cmpMarshaledBody always calls us with target as a pointer
+				return fmt.Errorf(
+					"CmpFormBody only accepts expected be a url.Values, a tdhttp.Q or a TestDeep operator allowing to match url.Values, but not type %s",
+					reflect.TypeOf(target).Elem())
+			}
+			return nil
+		},
+		expected)
+}
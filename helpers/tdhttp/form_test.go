@@ -0,0 +1,164 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp_test
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/helpers/tdhttp"
+	"github.com/maxatome/go-testdeep/helpers/tdutil"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func formServer() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/export", func(w http.ResponseWriter, req *http.Request) {
+		body := &tdhttp.MultipartBody{
+			MediaType: "multipart/mixed",
+			Boundary:  "BoUnDaRy",
+			Parts: []*tdhttp.MultipartPart{
+				tdhttp.NewMultipartPartString("meta", `{"count":2}`, "application/json"),
+				{
+					Name:     "file",
+					Filename: "users.csv",
+					Content:  strings.NewReader("id,name\n1,Bob\n2,Alice\n"),
+					Header:   http.Header{"Content-Type": {"text/csv"}},
+				},
+			},
+		}
+		w.Header().Set("Content-Type", body.ContentType())
+		io.Copy(w, body) //nolint: errcheck
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+		w.Write([]byte("access_token=abc123&token_type=bearer&scope=read&scope=write&expires_in=3600")) //nolint: errcheck
+	})
+
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, req *http.Request) {})
+
+	return mux
+}
+
+func TestCmpMultipartBody(t *testing.T) {
+	mux := formServer()
+
+	t.Run("OK", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(t, mux)
+
+		ta.Get("/export").
+			CmpStatus(http.StatusOK).
+			CmpMultipartBody(
+				tdhttp.MultipartResponsePart{Name: "meta", Content: `{"count":2}`},
+				td.Struct(
+					tdhttp.MultipartResponsePart{Name: "file", Filename: "users.csv"},
+					td.StructFields{
+						"Header":  td.SuperMapOf(http.Header{"Content-Type": {"text/csv"}}, nil),
+						"Content": td.HasPrefix("id,name\n"),
+					}),
+			)
+		td.CmpFalse(t, ta.Failed())
+
+		ta.Get("/export").
+			CmpMultipartBody(
+				td.Struct(tdhttp.MultipartResponsePart{Name: "meta"},
+					td.StructFields{"Content": td.Contains(`"count"`)}),
+				td.Ignore(),
+			)
+		td.CmpFalse(t, ta.Failed())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(tdutil.NewT("test"), mux)
+
+		td.CmpTrue(t, ta.CmpMultipartBody().Failed(), "no request sent")
+
+		td.CmpTrue(t,
+			ta.Get("/export").
+				CmpMultipartBody(tdhttp.MultipartResponsePart{Name: "meta", Content: `{"count":2}`}).
+				Failed(),
+			"missing part")
+
+		td.CmpTrue(t,
+			ta.Get("/export").
+				CmpMultipartBody(
+					tdhttp.MultipartResponsePart{Name: "meta", Content: `{"count":3}`},
+					td.Ignore(),
+				).
+				Failed(),
+			"bad content")
+
+		td.CmpTrue(t, ta.Get("/token").CmpMultipartBody().Failed(), "not multipart")
+		td.CmpTrue(t, ta.Get("/empty").CmpMultipartBody().Failed(), "empty body")
+	})
+}
+
+func TestCmpFormBody(t *testing.T) {
+	mux := formServer()
+
+	t.Run("OK", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(t, mux)
+
+		ta.Get("/token").
+			CmpStatus(http.StatusOK).
+			CmpFormBody(url.Values{
+				"access_token": {"abc123"},
+				"token_type":   {"bearer"},
+				"scope":        {"read", "write"},
+				"expires_in":   {"3600"},
+			})
+		td.CmpFalse(t, ta.Failed())
+
+		ta.Get("/token").
+			CmpFormBody(tdhttp.Q{
+				"access_token": "abc123",
+				"token_type":   "bearer",
+				"scope":        []string{"read", "write"},
+				"expires_in":   3600,
+			})
+		td.CmpFalse(t, ta.Failed())
+
+		ta.Get("/token").
+			CmpFormBody(td.SuperMapOf(
+				url.Values{"token_type": {"bearer"}},
+				td.MapEntries{"access_token": td.Len(1)},
+			))
+		td.CmpFalse(t, ta.Failed())
+
+		ta.Get("/token").
+			CmpFormBody(td.ContainsKey("scope"))
+		td.CmpFalse(t, ta.Failed())
+
+		ta.Get("/empty").
+			CmpFormBody(url.Values{})
+		td.CmpFalse(t, ta.Failed())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tt := tdutil.NewT("test")
+		ta := tdhttp.NewTestAPI(tt, mux)
+
+		td.CmpTrue(t, ta.CmpFormBody(url.Values{}).Failed(), "no request sent")
+
+		td.CmpTrue(t,
+			ta.Get("/token").CmpFormBody(tdhttp.Q{"token_type": "mac"}).Failed(),
+			"bad values")
+
+		td.CmpTrue(t,
+			ta.Get("/token").CmpFormBody(map[string]string{}).Failed(),
+			"bad expected type")
+
+		td.CmpTrue(t, tt.CatchFailNow(func() {
+			ta.CmpFormBody(tdhttp.Q{"bad": map[string]bool{}})
+		}))
+	})
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/flat"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// graphQLRequest is the standard JSON envelope of a GraphQL request.
+type graphQLRequest struct {
+	Query         string `json:"query"`
+	OperationName string `json:"operationName,omitempty"`
+	Variables     any    `json:"variables,omitempty"`
+}
+
+// GraphQLOperation is the name of the operation to execute among
+// those defined in a GraphQL query. It is passed among
+// headersQueryParams of [PostGraphQL] and [TestAPI.PostGraphQL],
+// and sent as the operationName member of the request:
+//
+//	ta.PostGraphQL("/graphql", queries, nil,
+//	  tdhttp.GraphQLOperation("GetUser"),
+//	  "Authorization", "Bearer tok3n",
+//	)
+//
+// If omitted, operationName is not sent, so the server executes the
+// only operation defined in the query.
+type GraphQLOperation string
+
+func newGraphQLRequest(target, query string, variables any, headersQueryParams ...any) (*http.Request, error) {
+	gqlReq := graphQLRequest{
+		Query:     query,
+		Variables: variables,
+	}
+
+	params := make([]any, 0, len(headersQueryParams))
+	for _, param := range flat.Interfaces(headersQueryParams...) {
+		if op, ok := param.(GraphQLOperation); ok {
+			gqlReq.OperationName = string(op)
+			continue
+		}
+		params = append(params, param)
+	}
+
+	return newJSONRequest(http.MethodPost, target, gqlReq, params...)
+}
+
+// PostGraphQL creates a HTTP POST with a GraphQL request as
+// body. query and variables are wrapped in the standard JSON
+// envelope, variables being omitted if nil. If query defines several
+// operations, the one to execute is selected using a
+// [GraphQLOperation] in headersQueryParams. "Content-Type" header is
+// automatically set to "application/json". Other headers can be
+// added via headersQueryParams, as in:
+//
+//	req := tdhttp.PostGraphQL("/graphql",
+//	  `query GetUser($id: ID!) { user(id: $id) { name } }`,
+//	  map[string]any{"id": 42},
+//	  "Authorization", "Bearer tok3n",
+//	)
+//
+// See [NewRequest] for all other possible formats accepted in
+// headersQueryParams.
+func PostGraphQL(target, query string, variables any, headersQueryParams ...any) *http.Request {
+	req, err := newGraphQLRequest(target, query, variables, headersQueryParams...)
+	if err != nil {
+		panic(err)
+	}
+	return req
+}
+
+// PostGraphQL sends a HTTP POST with a GraphQL request as body. See
+// [PostGraphQL] function for details about the request. Any Cmp* or
+// [TestAPI.NoBody] methods can now be called, [TestAPI.CmpGraphQLData]
+// and [TestAPI.CmpGraphQLErrors] being dedicated to GraphQL
+// responses:
+//
+//	ta.PostGraphQL("/graphql",
+//	  `query GetUser($id: ID!) { user(id: $id) { id name } }`,
+//	  map[string]any{"id": 42}).
+//	  CmpStatus(http.StatusOK).
+//	  CmpGraphQLData(td.JSON(`{"user": {"id": "42", "name": "Bob"}}`))
+//
+// Note that [TestAPI.Failed] status is reset just after this call.
+//
+// See [NewRequest] for all other possible formats accepted in
+// headersQueryParams.
+func (t *TestAPI) PostGraphQL(target, query string, variables any, headersQueryParams ...any) *TestAPI {
+	req, err := newGraphQLRequest(target, query, variables, headersQueryParams...)
+	if err != nil {
+		t.t.Helper()
+		t.t.Fatal(err)
+	}
+	return t.Request(req)
+}
+
+// CmpGraphQLData tests that the data member of the last request
+// response body, a GraphQL response, matches expected. As for
+// [TestAPI.CmpJSONBody], expected can be any type one can
+// [json.Unmarshal] into, or a [td.TestDeep] operator. The comparison
+// is done as [td.JSONPointer] operator does, so numbers can be
+// compared to any numeric type.
+//
+//	ta.PostGraphQL("/graphql", `{ users { name } }`, nil).
+//	  CmpStatus(http.StatusOK).
+//	  CmpGraphQLData(td.JSON(`{"users": [{"name": "Bob"}, {"name": "Alice"}]}`))
+//
+// As a GraphQL server can respond with a 200 status even if the
+// request failed, CmpGraphQLData first checks that the errors member
+// of the response is empty, and only compares data if it is. Errors
+// are accepted only if they have been explicitly expected by a call
+// to [TestAPI.CmpGraphQLErrors] done before CmpGraphQLData. So a
+// partial response can be checked using:
+//
+//	ta.PostGraphQL("/graphql", `{ users { name email } }`, nil).
+//	  CmpStatus(http.StatusOK).
+//	  CmpGraphQLErrors(td.JSON(`[{"message": "forbidden", "path": ["users", 1, "email"], "locations": Ignore()}]`)).
+//	  CmpGraphQLData(td.JSON(`{"users": [{"name": "Bob", "email": "bob@example.com"}, {"name": "Alice", "email": null}]}`))
+//
+// It fails if no request has been sent yet.
+func (t *TestAPI) CmpGraphQLData(expected any) *TestAPI {
+	defer t.t.AnchorsPersistTemporarily()()
+
+	t.t.Helper()
+
+	failed := t.bodyFailed
+	t.bodyFailed = false
+
+	if !t.graphQLErrorsExpected {
+		t.cmpMarshaledBody(false, json.Unmarshal, td.Code(checkNoGraphQLErrors))
+	}
+	if !t.bodyFailed {
+		t.cmpMarshaledBody(false, json.Unmarshal, td.JSONPointer("/data", expected))
+	}
+
+	t.bodyFailed = t.bodyFailed || failed
+	return t
+}
+
+// checkNoGraphQLErrors checks that body, a GraphQL response, does not
+// contain any error.
+func checkNoGraphQLErrors(body map[string]any) error {
+	errs, _ := body["errors"].([]any)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.Encode(errs) //nolint: errcheck
+	return &ctxerr.Error{
+		Message: `%%["errors"] is not empty`,
+		Summary: ctxerr.NewSummary(strings.TrimSuffix(buf.String(), "\n")),
+	}
+}
+
+// CmpGraphQLErrors tests that the errors member of the last request
+// response body, a GraphQL response, matches expected. As for
+// [TestAPI.CmpJSONBody], expected can be any type one can
+// [json.Unmarshal] into, or a [td.TestDeep] operator. The comparison
+// is done as [td.JSONPointer] operator does, so numbers can be
+// compared to any numeric type. The path and locations of errors can
+// be matched using operators, typically in [td.JSON]:
+//
+//	ta.PostGraphQL("/graphql", `query { user(id: 666) { name } }`, nil).
+//	  CmpStatus(http.StatusOK).
+//	  CmpGraphQLErrors(td.JSON(`
+//	[
+//	  {
+//	    "message":   "user not found",
+//	    "path":      ["user"],
+//	    "locations": [{"line": 1, "column": Gt(0)}]
+//	  }
+//	]`)).
+//	  CmpGraphQLData(td.JSON(`{"user": null}`))
+//
+// Calling CmpGraphQLErrors allows a subsequent call to
+// [TestAPI.CmpGraphQLData] on the same response to accept errors, so
+// CmpGraphQLErrors has to be called first.
+//
+// It fails if no request has been sent yet or if the errors member
+// is missing.
+func (t *TestAPI) CmpGraphQLErrors(expected any) *TestAPI {
+	defer t.t.AnchorsPersistTemporarily()()
+
+	t.t.Helper()
+
+	t.graphQLErrorsExpected = true
+	return t.cmpMarshaledBody(false, json.Unmarshal, td.JSONPointer("/errors", expected))
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/helpers/tdhttp"
+	"github.com/maxatome/go-testdeep/helpers/tdutil"
+	"github.com/maxatome/go-testdeep/internal/test"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestPostGraphQL(t *testing.T) {
+	check := func(query string, variables any, expectedBody string, params ...any) {
+		t.Helper()
+
+		req := tdhttp.PostGraphQL("/graphql", query, variables,
+			append([]any{"X-Foo", "bar"}, params...)...)
+		td.Cmp(t, req.Method, "POST")
+		td.Cmp(t, req.Header.Get("Content-Type"), "application/json")
+		td.Cmp(t, req.Header.Get("X-Foo"), "bar")
+
+		b, err := ioutil.ReadAll(req.Body)
+		if td.CmpNoError(t, err) {
+			td.Cmp(t, json.RawMessage(b), td.JSON(expectedBody))
+		}
+	}
+
+	check(`{ users { name } }`, nil,
+		`{"query": "{ users { name } }"}`)
+
+	check(`query GetUser($id: ID!) { user(id: $id) { name } }`,
+		map[string]int{"id": 42},
+		`{
+  "query":     "query GetUser($id: ID!) { user(id: $id) { name } }",
+  "variables": {"id": 42}
+}`)
+
+	check(`query A { a } query B { b }`, nil,
+		`{"query": "query A { a } query B { b }", "operationName": "B"}`,
+		tdhttp.GraphQLOperation("B"))
+
+	// Nested in a slice, as any other headersQueryParams
+	check(`query A { a } query B { b }`, nil,
+		`{"query": "query A { a } query B { b }", "operationName": "A"}`,
+		td.Flatten([]any{tdhttp.GraphQLOperation("A"), "X-Bar", "foo"}))
+
+	td.CmpPanic(t,
+		func() { tdhttp.PostGraphQL("/graphql", "{ x }", func() {}) },
+		td.Contains("json: unsupported type: func()"))
+}
+
+func graphQLServer() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql", func(w http.ResponseWriter, req *http.Request) {
+		var gqlReq struct {
+			Query         string         `json:"query"`
+			OperationName string         `json:"operationName"`
+			Variables     map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&gqlReq); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Without operationName, the only operation of the query is executed
+		op := gqlReq.OperationName
+		if op == "" {
+			switch {
+			case strings.HasPrefix(gqlReq.Query, "query GetUser"):
+				op = "GetUser"
+			case strings.HasPrefix(gqlReq.Query, "mutation Fail"):
+				op = "Fail"
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch op {
+		case "GetUser":
+			if gqlReq.Variables["id"] == float64(42) {
+				w.Write([]byte(`{"data": {"user": {"id": "42", "name": "Bob"}}}`)) //nolint: errcheck
+				return
+			}
+			w.Write([]byte(`{
+  "errors": [{
+    "message":   "user not found",
+    "path":      ["user"],
+    "locations": [{"line": 1, "column": 26}]
+  }],
+  "data": {"user": null}
+}`)) //nolint: errcheck
+		case "Fail":
+			w.Write([]byte(`{"errors": [{"message": "syntax error"}]}`)) //nolint: errcheck
+		default:
+			w.Write([]byte(`{"data": {"ok": true}}`)) //nolint: errcheck
+		}
+	})
+	return mux
+}
+
+func TestCmpGraphQL(t *testing.T) {
+	mux := graphQLServer()
+
+	const getUser = `query GetUser($id: ID!) { user(id: $id) { id name } }`
+
+	t.Run("OK", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(t, mux)
+
+		ta.PostGraphQL("/graphql", getUser, map[string]int{"id": 42}).
+			CmpStatus(http.StatusOK).
+			CmpGraphQLData(td.JSON(`{"user": {"id": "42", "name": "Bob"}}`))
+		td.CmpFalse(t, ta.Failed())
+
+		type User struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}
+		ta.PostGraphQL("/graphql", getUser, map[string]int{"id": 42}).
+			CmpGraphQLData(map[string]User{"user": {ID: "42", Name: "Bob"}})
+		td.CmpFalse(t, ta.Failed())
+
+		// Partial response
+		ta.PostGraphQL("/graphql", getUser, map[string]int{"id": 666}).
+			CmpStatus(http.StatusOK).
+			CmpGraphQLErrors(td.JSON(`
+[
+  {
+    "message":   "user not found",
+    "path":      ["user"],
+    "locations": [{"line": 1, "column": Gt(0)}]
+  }
+]`)).
+			CmpGraphQLData(td.JSON(`{"user": null}`))
+		td.CmpFalse(t, ta.Failed())
+
+		ta.PostGraphQL("/graphql", `mutation Fail { x }`, nil).
+			CmpGraphQLErrors(td.Len(1))
+		td.CmpFalse(t, ta.Failed())
+
+		// Operation selected among several ones
+		ta.PostGraphQL("/graphql", `mutation Fail { x } `+getUser,
+			map[string]int{"id": 42},
+			tdhttp.GraphQLOperation("GetUser")).
+			CmpGraphQLData(td.JSON(`{"user": {"id": "42", "name": "Bob"}}`))
+		td.CmpFalse(t, ta.Failed())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tt := tdutil.NewT("test")
+		ta := tdhttp.NewTestAPI(tt, mux)
+
+		td.CmpTrue(t, ta.CmpGraphQLData(td.Ignore()).Failed(), "no request sent")
+
+		td.CmpTrue(t,
+			ta.PostGraphQL("/graphql", `{ ok }`, nil).
+				CmpGraphQLData(td.JSON(`{"ok": false}`)).
+				Failed(),
+			"bad data")
+
+		// Unexpected errors, even if data matches
+		td.CmpTrue(t,
+			ta.PostGraphQL("/graphql", getUser, map[string]int{"id": 666}).
+				CmpGraphQLData(td.JSON(`{"user": null}`)).
+				Failed(),
+			"unexpected errors")
+
+		td.CmpTrue(t,
+			ta.PostGraphQL("/graphql", `{ ok }`, nil).
+				CmpGraphQLErrors(td.Ignore()).
+				Failed(),
+			"missing errors")
+
+		td.CmpTrue(t,
+			ta.PostGraphQL("/graphql", `mutation Fail { x }`, nil).
+				CmpGraphQLErrors(td.Len(2)).
+				Failed(),
+			"bad errors")
+	})
+
+	t.Run("Unexpected errors message", func(t *testing.T) {
+		tb := test.NewTestingTB("test")
+		ta := tdhttp.NewTestAPI(tb, mux)
+
+		ta.PostGraphQL("/graphql", `mutation Fail { x }`, nil).
+			CmpGraphQLData(td.Ignore())
+		td.CmpTrue(t, ta.Failed())
+		td.Cmp(t, tb.LastMessage(), td.All(
+			td.Contains(`Response.Body["errors"] is not empty`),
+			td.Contains(`"message": "syntax error"`),
+		))
+		td.Cmp(t, strings.Count(strings.Join(tb.Messages, "\n"), "Failed test"), 1,
+			"data is not compared")
+
+		// Errors have to be expected before data is checked
+		tb.ResetMessages()
+		ta.PostGraphQL("/graphql", getUser, map[string]int{"id": 666}).
+			CmpGraphQLData(td.JSON(`{"user": null}`))
+		td.Cmp(t, tb.LastMessage(), td.Contains(`Response.Body["errors"] is not empty`))
+		ta.CmpGraphQLErrors(td.Len(1))
+		td.CmpTrue(t, ta.Failed())
+
+		// Failed has no side effect
+		num := len(tb.Messages)
+		td.CmpTrue(t, ta.Failed())
+		td.CmpLen(t, tb.Messages, num)
+	})
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package internal
+
+import (
+	"crypto/rand"
+	"crypto/sha1" //nolint: gosec
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// WebSocket opcodes, as defined in RFC 6455.
+const (
+	WSContinuation byte = 0x0
+	WSText         byte = 0x1
+	WSBinary       byte = 0x2
+	WSClose        byte = 0x8
+	WSPing         byte = 0x9
+	WSPong         byte = 0xa
+)
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WSMaxPayload is the maximum payload size accepted by
+// [ReadWebSocketFrame].
+const WSMaxPayload = 64 << 20
+
+// WebSocketKey returns a new random Sec-WebSocket-Key header value.
+func WebSocketKey() string {
+	var key [16]byte
+	rand.Read(key[:]) //nolint: errcheck
+	return base64.StdEncoding.EncodeToString(key[:])
+}
+
+// WebSocketAccept returns the Sec-WebSocket-Accept header value
+// corresponding to the Sec-WebSocket-Key header value key.
+func WebSocketAccept(key string) string {
+	h := sha1.New()               //nolint: gosec
+	h.Write([]byte(key + wsGUID)) //nolint: errcheck
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// WebSocketFrame is a RFC 6455 frame.
+type WebSocketFrame struct {
+	Fin     bool
+	Opcode  byte
+	Payload []byte
+}
+
+// IsControl returns true if f is a control frame.
+func (f WebSocketFrame) IsControl() bool {
+	return f.Opcode&0x8 != 0
+}
+
+// WriteWebSocketFrame writes f to w. If mask is true, as required for
+// frames sent by a client, the payload is masked using a random key.
+func WriteWebSocketFrame(w io.Writer, f WebSocketFrame, mask bool) error {
+	header := make([]byte, 2, 14)
+	header[0] = f.Opcode & 0xf
+	if f.Fin {
+		header[0] |= 0x80
+	}
+
+	var maskBit byte
+	if mask {
+		maskBit = 0x80
+	}
+
+	l := len(f.Payload)
+	switch {
+	case l < 126:
+		header[1] = maskBit | byte(l)
+	case l <= 0xffff:
+		header[1] = maskBit | 126
+		header = append(header, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(l))
+	default:
+		header[1] = maskBit | 127
+		header = append(header, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(l))
+	}
+
+	payload := f.Payload
+	if mask {
+		var key [4]byte
+		rand.Read(key[:]) //nolint: errcheck
+		header = append(header, key[:]...)
+
+		payload = make([]byte, l)
+		for i, b := range f.Payload {
+			payload[i] = b ^ key[i%4]
+		}
+	}
+
+	if _, err := w.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ReadWebSocketFrame reads a frame from r, unmasking its payload if
+// needed.
+func ReadWebSocketFrame(r io.Reader) (WebSocketFrame, error) {
+	var (
+		f      WebSocketFrame
+		header [2]byte
+	)
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return f, err
+	}
+	f.Fin = header[0]&0x80 != 0
+	f.Opcode = header[0] & 0xf
+	if header[0]&0x70 != 0 {
+		return f, errors.New("reserved bits set")
+	}
+
+	l := uint64(header[1] & 0x7f)
+	switch l {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return f, err
+		}
+		l = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return f, err
+		}
+		l = binary.BigEndian.Uint64(ext[:])
+	}
+	if l > WSMaxPayload {
+		return f, errors.New("frame payload too large")
+	}
+
+	var key [4]byte
+	masked := header[1]&0x80 != 0
+	if masked {
+		if _, err := io.ReadFull(r, key[:]); err != nil {
+			return f, err
+		}
+	}
+
+	f.Payload = make([]byte, l)
+	if _, err := io.ReadFull(r, f.Payload); err != nil {
+		return f, err
+	}
+	if masked {
+		for i := range f.Payload {
+			f.Payload[i] ^= key[i%4]
+		}
+	}
+	return f, nil
+}
+
+// WebSocketClosePayload returns the payload of a close frame
+// containing code and reason.
+func WebSocketClosePayload(code int, reason string) []byte {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	return append(payload, reason...)
+}
+
+// ParseWebSocketClosePayload returns the code and the reason
+// contained in payload, the payload of a close frame. As RFC 6455
+// specifies, an empty payload means 1005 code (no status received).
+func ParseWebSocketClosePayload(payload []byte) (code int, reason string) {
+	if len(payload) < 2 {
+		return 1005, ""
+	}
+	return int(binary.BigEndian.Uint16(payload)), string(payload[2:])
+}
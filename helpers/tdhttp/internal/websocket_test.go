@@ -0,0 +1,70 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package internal_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/helpers/tdhttp/internal"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func TestWebSocketAccept(t *testing.T) {
+	// Example of RFC 6455 section 1.3
+	td.Cmp(t, internal.WebSocketAccept("dGhlIHNhbXBsZSBub25jZQ=="),
+		"s3pPLMBiTxaQ9kYGzzhZRbK+xOo=")
+
+	td.CmpLen(t, internal.WebSocketKey(), 24)
+}
+
+func TestWebSocketFrame(t *testing.T) {
+	for _, size := range []int{0, 125, 126, 0xffff, 0x10000} {
+		for _, mask := range []bool{false, true} {
+			f := internal.WebSocketFrame{
+				Fin:     size%2 == 0,
+				Opcode:  internal.WSBinary,
+				Payload: bytes.Repeat([]byte{'x'}, size),
+			}
+
+			var buf bytes.Buffer
+			if !td.CmpNoError(t, internal.WriteWebSocketFrame(&buf, f, mask)) {
+				continue
+			}
+			got, err := internal.ReadWebSocketFrame(&buf)
+			if td.CmpNoError(t, err) {
+				td.Cmp(t, got, f, "size=%d mask=%t", size, mask)
+			}
+			td.CmpZero(t, buf.Len())
+		}
+	}
+
+	td.CmpFalse(t, internal.WebSocketFrame{Opcode: internal.WSText}.IsControl())
+	td.CmpTrue(t, internal.WebSocketFrame{Opcode: internal.WSPing}.IsControl())
+
+	// Errors
+	_, err := internal.ReadWebSocketFrame(bytes.NewReader([]byte{0xc1, 0}))
+	td.CmpString(t, err, "reserved bits set")
+
+	_, err = internal.ReadWebSocketFrame(bytes.NewReader(
+		[]byte{0x82, 127, 0, 0, 0, 0, 0xff, 0, 0, 0}))
+	td.CmpString(t, err, "frame payload too large")
+
+	_, err = internal.ReadWebSocketFrame(bytes.NewReader([]byte{0x82, 5, 'a'}))
+	td.CmpString(t, err, "unexpected EOF")
+}
+
+func TestWebSocketClosePayload(t *testing.T) {
+	code, reason := internal.ParseWebSocketClosePayload(
+		internal.WebSocketClosePayload(4000, "bye"))
+	td.Cmp(t, code, 4000)
+	td.Cmp(t, reason, "bye")
+
+	code, reason = internal.ParseWebSocketClosePayload(nil)
+	td.Cmp(t, code, 1005)
+	td.Cmp(t, reason, "")
+}
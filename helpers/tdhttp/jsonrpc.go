@@ -0,0 +1,408 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/types"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// JSONRPCCall is a JSON-RPC 2.0 call, as sent by [TestAPI.BatchJSONRPC].
+type JSONRPCCall struct {
+	Method string
+	// Params are omitted from the request if nil.
+	Params any
+	// Notification is true if the call is a notification, so is
+	// sent without id and does not expect any response.
+	Notification bool
+}
+
+// jsonRPCRequest is the JSON envelope of a JSON-RPC 2.0 call.
+type jsonRPCRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+	ID      *int64 `json:"id,omitempty"`
+}
+
+// jsonRPCSent is a JSON-RPC call sent by the last request.
+type jsonRPCSent struct {
+	id           int64
+	method       string
+	notification bool
+}
+
+// jsonRPCState records the JSON-RPC calls sent by the last request,
+// and the responses received, indexed by id.
+type jsonRPCState struct {
+	batch bool
+	calls []jsonRPCSent
+	next  int // next call to check
+
+	parsed      bool
+	parseFailed bool
+	responses   map[string]map[string]json.RawMessage
+}
+
+func (t *TestAPI) sendJSONRPC(target string, calls []JSONRPCCall, batch bool, headersQueryParams ...any) *TestAPI {
+	t.t.Helper()
+
+	sent := make([]jsonRPCSent, len(calls))
+	reqs := make([]jsonRPCRequest, len(calls))
+	allNotifications := true
+	for i, call := range calls {
+		reqs[i] = jsonRPCRequest{
+			JSONRPC: "2.0",
+			Method:  call.Method,
+			Params:  call.Params,
+		}
+		sent[i] = jsonRPCSent{method: call.Method, notification: call.Notification}
+		if !call.Notification {
+			t.jsonRPCID++
+			id := t.jsonRPCID
+			reqs[i].ID = &id
+			sent[i].id = id
+			allNotifications = false
+		}
+	}
+
+	var body any = reqs
+	if !batch {
+		body = reqs[0]
+	}
+
+	req, err := newJSONRequest(http.MethodPost, target, body, headersQueryParams...)
+	if err != nil {
+		t.t.Fatal(err)
+	}
+	t.Request(req)
+
+	t.jsonRPC = &jsonRPCState{batch: batch, calls: sent}
+
+	// Notifications must not be answered
+	if allNotifications && len(calls) > 0 {
+		t.NoBody()
+	}
+	return t
+}
+
+// CallJSONRPC sends a HTTP POST with a JSON-RPC 2.0 call of method
+// as body. params are omitted if nil. The id of the call is
+// automatically generated, as a number incremented at each call
+// sent by t. "Content-Type" header is automatically set to
+// "application/json".
+//
+//	ta.CallJSONRPC("/rpc", "sum", []int{1, 2, 3}).
+//	  CmpStatus(http.StatusOK).
+//	  CmpJSONRPCResult(6)
+//
+//	ta.CallJSONRPC("/rpc", "sum", "bad").
+//	  CmpStatus(http.StatusOK).
+//	  CmpJSONRPCError(-32602, "Invalid params", nil)
+//
+// Any Cmp* or [TestAPI.NoBody] methods can now be called,
+// [TestAPI.CmpJSONRPCResult] and [TestAPI.CmpJSONRPCError] being
+// dedicated to JSON-RPC responses.
+//
+// Note that [TestAPI.Failed] status is reset just after this call.
+//
+// See [NewRequest] for all possible formats accepted in headersQueryParams.
+func (t *TestAPI) CallJSONRPC(target, method string, params any, headersQueryParams ...any) *TestAPI {
+	t.t.Helper()
+	return t.sendJSONRPC(target,
+		[]JSONRPCCall{{Method: method, Params: params}}, false,
+		headersQueryParams...)
+}
+
+// NotifyJSONRPC sends a HTTP POST with a JSON-RPC 2.0 notification
+// of method as body, so a call without id. params are omitted if
+// nil. "Content-Type" header is automatically set to
+// "application/json".
+//
+//	ta.NotifyJSONRPC("/rpc", "log", []string{"hello"}).
+//	  CmpStatus(http.StatusNoContent)
+//
+// As JSON-RPC 2.0 specifies the server must not reply to a
+// notification, it fails if the response has a body.
+//
+// Note that [TestAPI.Failed] status is reset just after this call.
+//
+// See [NewRequest] for all possible formats accepted in headersQueryParams.
+func (t *TestAPI) NotifyJSONRPC(target, method string, params any, headersQueryParams ...any) *TestAPI {
+	t.t.Helper()
+	return t.sendJSONRPC(target,
+		[]JSONRPCCall{{Method: method, Params: params, Notification: true}}, false,
+		headersQueryParams...)
+}
+
+// BatchJSONRPC sends a HTTP POST with a batch of JSON-RPC 2.0 calls
+// as body. As for [TestAPI.CallJSONRPC], the ids of calls are
+// automatically generated, except for notifications. "Content-Type"
+// header is automatically set to "application/json".
+//
+// Each call response can then be checked, in calls order and
+// whatever the order of the responses in the batch, using
+// [TestAPI.CmpJSONRPCResult] or [TestAPI.CmpJSONRPCError], that
+// correlate them by id. Notifications are skipped as they have no
+// response:
+//
+//	ta.BatchJSONRPC("/rpc", []tdhttp.JSONRPCCall{
+//	  {Method: "sum", Params: []int{1, 2}},
+//	  {Method: "log", Params: []string{"hi"}, Notification: true},
+//	  {Method: "unknown"},
+//	}).
+//	  CmpStatus(http.StatusOK).
+//	  CmpJSONRPCResult(3).                              // sum
+//	  CmpJSONRPCError(-32601, td.Ignore(), td.Ignore()) // unknown
+//
+// If the batch only contains notifications, it fails if the response
+// has a body, as JSON-RPC 2.0 specifies the server must not reply.
+//
+// Note that [TestAPI.Failed] status is reset just after this call.
+//
+// See [NewRequest] for all possible formats accepted in headersQueryParams.
+func (t *TestAPI) BatchJSONRPC(target string, calls []JSONRPCCall, headersQueryParams ...any) *TestAPI {
+	t.t.Helper()
+	return t.sendJSONRPC(target, calls, true, headersQueryParams...)
+}
+
+// jsonRPCFail reports a failure on a JSON-RPC response.
+func (t *TestAPI) jsonRPCFail(root, message, summary, testName string) {
+	t.t.Helper()
+	t.t.RootName(root).
+		Code(false, func(bool) error {
+			return &ctxerr.Error{
+				Message: message,
+				Summary: ctxerr.NewSummary(summary),
+			}
+		},
+			t.name+testName)
+	t.bodyFailed = true
+	if t.autoDumpResponse {
+		t.dumpResponse()
+	}
+}
+
+// parseJSONRPCResponses unmarshals the last response body and
+// indexes the JSON-RPC responses it contains by id.
+func (t *TestAPI) parseJSONRPCResponses(st *jsonRPCState) {
+	t.t.Helper()
+
+	st.parsed = true
+	st.responses = map[string]map[string]json.RawMessage{}
+
+	var (
+		responses []map[string]json.RawMessage
+		err       error
+	)
+	if st.batch {
+		err = json.Unmarshal(t.response.Body.Bytes(), &responses)
+	} else {
+		var resp map[string]json.RawMessage
+		err = json.Unmarshal(t.response.Body.Bytes(), &resp)
+		responses = append(responses, resp)
+	}
+	if !t.t.RootName("unmarshal(Response.Body)").
+		CmpNoError(err, t.name+"body unmarshaling") {
+		st.parseFailed = true
+		t.bodyFailed = true
+		t.dumpResponse()
+		return
+	}
+
+	for _, resp := range responses {
+		if id, ok := resp["id"]; ok {
+			st.responses[strings.TrimSpace(string(id))] = resp
+		}
+	}
+}
+
+// nextJSONRPCResponse returns the response of the next JSON-RPC call
+// to check, with the root name to use when reporting failures on it.
+func (t *TestAPI) nextJSONRPCResponse(testName string) (map[string]json.RawMessage, string, bool) {
+	t.t.Helper()
+
+	if !t.checkRequestSent() {
+		t.bodyFailed = true
+		return nil, "", false
+	}
+
+	st := t.jsonRPC
+	if st == nil {
+		t.jsonRPCFail("JSON-RPC call", "%% not sent!",
+			"Last request is not a JSON-RPC call", testName)
+		return nil, "", false
+	}
+
+	for st.next < len(st.calls) && st.calls[st.next].notification {
+		st.next++
+	}
+	if st.next >= len(st.calls) {
+		t.jsonRPCFail("JSON-RPC response", "%% not found",
+			"All JSON-RPC calls of last request have already been checked", testName)
+		return nil, "", false
+	}
+	call := st.calls[st.next]
+	st.next++
+
+	if !st.parsed {
+		t.parseJSONRPCResponses(st)
+	}
+	if st.parseFailed {
+		return nil, "", false
+	}
+
+	root := "Response.Body"
+	if st.batch {
+		root = fmt.Sprintf("Response.Body[id=%d]", call.id)
+	}
+
+	resp, ok := st.responses[strconv.FormatInt(call.id, 10)]
+	if !ok {
+		t.jsonRPCFail(root, "%% not found",
+			fmt.Sprintf("No response for %s call with id %d", call.method, call.id),
+			testName)
+		return nil, "", false
+	}
+
+	if version := string(resp["jsonrpc"]); version != `"2.0"` {
+		t.jsonRPCFail(root, `%%["jsonrpc"] is not "2.0"`, "got: "+version, testName)
+		return nil, "", false
+	}
+	return resp, root, true
+}
+
+// cmpJSONRPCMember unmarshals raw in a value of the type of expected,
+// or of the type behind it if it is an operator, then compares it to
+// expected. A missing member is considered as null.
+func (t *TestAPI) cmpJSONRPCMember(root string, raw json.RawMessage, expected any, testName string) bool {
+	t.t.Helper()
+
+	if raw == nil {
+		raw = json.RawMessage("null")
+	}
+
+	typ := reflect.TypeOf(expected)
+	if op, ok := expected.(td.TestDeep); ok {
+		typ = op.TypeBehind()
+	}
+	if typ == nil {
+		typ = types.Interface
+	}
+
+	ptr := reflect.New(typ)
+	if !t.t.RootName("unmarshal("+root+")").
+		CmpNoError(json.Unmarshal(raw, ptr.Interface()), t.name+testName+" unmarshaling") ||
+		!t.t.RootName(root).Cmp(ptr.Elem().Interface(), expected, t.name+testName) {
+		t.bodyFailed = true
+		if t.autoDumpResponse {
+			t.dumpResponse()
+		}
+		return false
+	}
+	return true
+}
+
+// CmpJSONRPCResult tests that the response of the next JSON-RPC call
+// of the last request is a success and that its result matches
+// expected. As for [TestAPI.CmpJSONBody], expected can be any type
+// one can [json.Unmarshal] into, or a [td.TestDeep] operator.
+//
+//	ta.CallJSONRPC("/rpc", "user.get", map[string]int{"id": 42}).
+//	  CmpStatus(http.StatusOK).
+//	  CmpJSONRPCResult(td.JSON(`{"id": 42, "name": "Bob"}`))
+//
+// For a single call, the next call is the call itself. For a batch
+// sent by [TestAPI.BatchJSONRPC], each call of CmpJSONRPCResult or
+// [TestAPI.CmpJSONRPCError] checks the response of the following
+// call, notifications excluded.
+//
+// It fails if no request has been sent yet, if the last request is
+// not a JSON-RPC call, if all its calls have already been checked, if
+// the response of the call is not found, or if it is an error.
+func (t *TestAPI) CmpJSONRPCResult(expected any) *TestAPI {
+	defer t.t.AnchorsPersistTemporarily()()
+
+	t.t.Helper()
+
+	const testName = "JSON-RPC result"
+	resp, root, ok := t.nextJSONRPCResponse(testName)
+	if !ok {
+		return t
+	}
+
+	if e := resp["error"]; e != nil && string(e) != "null" {
+		t.jsonRPCFail(root, `%%["error"] is present`, string(e), testName)
+		return t
+	}
+
+	t.cmpJSONRPCMember(root+`["result"]`, resp["result"], expected, testName)
+	return t
+}
+
+// CmpJSONRPCError tests that the response of the next JSON-RPC call
+// of the last request is an error and that its code, message and
+// data match expectedCode, expectedMessage and expectedData. Each of
+// them can be a value or a [td.TestDeep] operator, data being
+// unmarshaled as [TestAPI.CmpJSONBody] does. A missing data is
+// considered as nil, use [td.Ignore] to not check it.
+//
+//	ta.CallJSONRPC("/rpc", "user.get", map[string]int{"id": 666}).
+//	  CmpStatus(http.StatusOK).
+//	  CmpJSONRPCError(-32000, "user not found", td.JSON(`{"id": 666}`))
+//
+// See [TestAPI.CmpJSONRPCResult] to know which call is checked when
+// a batch has been sent.
+//
+// It fails if no request has been sent yet, if the last request is
+// not a JSON-RPC call, if all its calls have already been checked, if
+// the response of the call is not found, or if it is not an error.
+func (t *TestAPI) CmpJSONRPCError(expectedCode, expectedMessage, expectedData any) *TestAPI {
+	defer t.t.AnchorsPersistTemporarily()()
+
+	t.t.Helper()
+
+	const testName = "JSON-RPC error"
+	resp, root, ok := t.nextJSONRPCResponse(testName)
+	if !ok {
+		return t
+	}
+
+	raw := resp["error"]
+	if raw == nil || string(raw) == "null" {
+		t.jsonRPCFail(root, `%%["error"] not found`, "result: "+string(resp["result"]), testName)
+		return t
+	}
+
+	root += `["error"]`
+
+	var e struct {
+		Code    json.RawMessage `json:"code"`
+		Message json.RawMessage `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if !t.t.RootName("unmarshal("+root+")").
+		CmpNoError(json.Unmarshal(raw, &e), t.name+testName+" unmarshaling") {
+		t.bodyFailed = true
+		t.dumpResponse()
+		return t
+	}
+
+	if t.cmpJSONRPCMember(root+`["code"]`, e.Code, expectedCode, testName+" code") &&
+		t.cmpJSONRPCMember(root+`["message"]`, e.Message, expectedMessage, testName+" message") {
+		t.cmpJSONRPCMember(root+`["data"]`, e.Data, expectedData, testName+" data")
+	}
+	return t
+}
@@ -0,0 +1,225 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/helpers/tdhttp"
+	"github.com/maxatome/go-testdeep/helpers/tdutil"
+	"github.com/maxatome/go-testdeep/internal/test"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+type jsonRPCReq struct {
+	JSONRPC string           `json:"jsonrpc"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params"`
+	ID      *json.RawMessage `json:"id"`
+}
+
+func (r jsonRPCReq) answer() any {
+	if r.ID == nil {
+		return nil // notification
+	}
+	resp := map[string]any{"jsonrpc": "2.0", "id": r.ID}
+	switch r.Method {
+	case "sum":
+		var nums []int
+		if json.Unmarshal(r.Params, &nums) != nil {
+			resp["error"] = map[string]any{"code": -32602, "message": "Invalid params"}
+			break
+		}
+		sum := 0
+		for _, n := range nums {
+			sum += n
+		}
+		resp["result"] = sum
+	case "user.get":
+		resp["error"] = map[string]any{
+			"code":    -32000,
+			"message": "user not found",
+			"data":    map[string]int{"id": 666},
+		}
+	case "bad-version":
+		resp["jsonrpc"] = "1.0"
+		resp["result"] = true
+	case "bad-id":
+		resp["id"] = 0
+		resp["result"] = true
+	default:
+		resp["error"] = map[string]any{"code": -32601, "message": "Method not found"}
+	}
+	return resp
+}
+
+func jsonRPCServer() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rpc", func(w http.ResponseWriter, req *http.Request) {
+		if id := req.Header.Get("X-Request-Id"); id != "" {
+			w.Header().Set("X-Request-Id", id)
+		}
+
+		var body json.RawMessage
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var resp any
+		if bytes.HasPrefix(body, []byte("[")) {
+			var reqs []jsonRPCReq
+			json.Unmarshal(body, &reqs) //nolint: errcheck
+			// Answer in reverse order
+			var resps []any
+			for i := len(reqs) - 1; i >= 0; i-- {
+				if r := reqs[i].answer(); r != nil {
+					resps = append(resps, r)
+				}
+			}
+			if resps != nil {
+				resp = resps
+			}
+		} else {
+			var r jsonRPCReq
+			json.Unmarshal(body, &r) //nolint: errcheck
+			resp = r.answer()
+		}
+
+		if resp == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp) //nolint: errcheck
+	})
+
+	mux.HandleFunc("/chatty", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": null, "result": "ack"}`)) //nolint: errcheck
+	})
+
+	return mux
+}
+
+func TestJSONRPC(t *testing.T) {
+	mux := jsonRPCServer()
+
+	t.Run("OK", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(t, mux)
+
+		ta.CallJSONRPC("/rpc", "sum", []int{1, 2, 3}).
+			CmpStatus(http.StatusOK).
+			CmpJSONBody(td.JSON(`{"jsonrpc": "2.0", "id": 1, "result": 6}`)).
+			CmpJSONRPCResult(6)
+		td.CmpFalse(t, ta.Failed())
+
+		ta.CallJSONRPC("/rpc", "sum", []int{4, 5}).
+			CmpJSONBody(td.SuperJSONOf(`{"id": 2}`)).
+			CmpJSONRPCResult(td.Between(8, 10))
+		td.CmpFalse(t, ta.Failed())
+
+		ta.CallJSONRPC("/rpc", "sum", "bad").
+			CmpJSONRPCError(-32602, "Invalid params", nil)
+		td.CmpFalse(t, ta.Failed())
+
+		ta.CallJSONRPC("/rpc", "user.get", map[string]int{"id": 666}).
+			CmpJSONRPCError(td.Lt(-31999), td.HasSuffix("not found"), td.JSON(`{"id": 666}`))
+		td.CmpFalse(t, ta.Failed())
+
+		ta.NotifyJSONRPC("/rpc", "log", []string{"hello"}).
+			CmpStatus(http.StatusNoContent)
+		td.CmpFalse(t, ta.Failed())
+
+		ta.BatchJSONRPC("/rpc", []tdhttp.JSONRPCCall{
+			{Method: "sum", Params: []int{1, 2}},
+			{Method: "log", Params: []string{"hi"}, Notification: true},
+			{Method: "unknown"},
+			{Method: "sum", Params: []int{}},
+		}, "X-Request-Id", "batch-1").
+			CmpStatus(http.StatusOK).
+			CmpHeader(td.SuperMapOf(http.Header{"X-Request-Id": {"batch-1"}}, nil)).
+			CmpJSONBody(td.Len(3)).
+			CmpJSONRPCResult(3).
+			CmpJSONRPCError(-32601, td.Ignore(), td.Ignore()).
+			CmpJSONRPCResult(0)
+		td.CmpFalse(t, ta.Failed())
+
+		ta.BatchJSONRPC("/rpc", []tdhttp.JSONRPCCall{
+			{Method: "log", Notification: true},
+			{Method: "log", Notification: true},
+		}).
+			CmpStatus(http.StatusNoContent)
+		td.CmpFalse(t, ta.Failed())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tb := test.NewTestingTB("test")
+		ta := tdhttp.NewTestAPI(tb, mux)
+
+		td.CmpTrue(t, ta.CmpJSONRPCResult(td.Ignore()).Failed(), "no request sent")
+
+		td.CmpTrue(t, ta.Get("/rpc").CmpJSONRPCResult(td.Ignore()).Failed(),
+			"not a JSON-RPC call")
+		td.Cmp(t, tb.LastMessage(), td.Contains("JSON-RPC call not sent!"))
+
+		td.CmpTrue(t,
+			ta.CallJSONRPC("/rpc", "sum", []int{1}).
+				CmpJSONRPCResult(1).
+				CmpJSONRPCResult(1).
+				Failed(),
+			"already checked")
+		td.Cmp(t, tb.LastMessage(), td.Contains("already been checked"))
+
+		td.CmpTrue(t,
+			ta.CallJSONRPC("/rpc", "sum", []int{1}).CmpJSONRPCResult(2).Failed(),
+			"bad result")
+		td.Cmp(t, tb.LastMessage(), td.Contains(`Response.Body["result"]: values differ`))
+
+		td.CmpTrue(t,
+			ta.CallJSONRPC("/rpc", "unknown", nil).CmpJSONRPCResult(td.Ignore()).Failed(),
+			"error instead of result")
+		td.Cmp(t, tb.LastMessage(), td.All(
+			td.Contains(`Response.Body["error"] is present`),
+			td.Contains("Method not found"),
+		))
+
+		td.CmpTrue(t,
+			ta.CallJSONRPC("/rpc", "sum", nil).CmpJSONRPCError(-32601, td.Ignore(), td.Ignore()).Failed(),
+			"bad error code")
+		td.Cmp(t, tb.LastMessage(), td.Contains(`Response.Body["error"]["code"]: values differ`))
+
+		td.CmpTrue(t,
+			ta.CallJSONRPC("/rpc", "sum", []int{1}).CmpJSONRPCError(td.Ignore(), td.Ignore(), nil).Failed(),
+			"result instead of error")
+		td.Cmp(t, tb.LastMessage(), td.Contains(`Response.Body["error"] not found`))
+
+		td.CmpTrue(t,
+			ta.CallJSONRPC("/rpc", "bad-version", nil).CmpJSONRPCResult(true).Failed(),
+			"bad version")
+		td.Cmp(t, tb.LastMessage(), td.Contains(`Response.Body["jsonrpc"] is not "2.0"`))
+
+		td.CmpTrue(t,
+			ta.BatchJSONRPC("/rpc", []tdhttp.JSONRPCCall{{Method: "bad-id"}}).
+				CmpJSONRPCResult(true).
+				Failed(),
+			"response not found")
+		td.Cmp(t, tb.LastMessage(), td.Re(`Response\.Body\[id=\d+\] not found`))
+
+		td.CmpTrue(t, ta.NotifyJSONRPC("/chatty", "log", nil).Failed(),
+			"notification answered")
+	})
+
+	t.Run("Fatal", func(t *testing.T) {
+		tt := tdutil.NewT("test")
+		ta := tdhttp.NewTestAPI(tt, mux)
+
+		td.CmpTrue(t, tt.CatchFailNow(func() { ta.CallJSONRPC("/rpc", "sum", func() {}) }))
+	})
+}
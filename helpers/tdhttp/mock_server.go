@@ -0,0 +1,439 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/internal/color"
+	"github.com/maxatome/go-testdeep/internal/location"
+	"github.com/maxatome/go-testdeep/internal/types"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// MockRequest is the representation of a request received by a
+// [MockServer], against which the expected request of each
+// [MockServer.Expect] call is compared.
+type MockRequest struct {
+	Method string
+	Host   string
+	Path   string
+	Query  url.Values
+	Header http.Header
+}
+
+// MockServer is a mock of an HTTP server, allowing to test HTTP
+// clients. It replies to requests using canned responses, depending
+// on expectations registered using [MockServer.Expect].
+//
+// It can be used as an [http.Handler], and so be served by an
+// [httptest.Server]:
+//
+//	mock := tdhttp.NewMockServer(t)
+//	srv := httptest.NewServer(mock)
+//	defer srv.Close()
+//
+// or as an [http.RoundTripper], to be used as the transport of the
+// tested client, see [MockServer.Client].
+//
+// At the end of the test, [MockServer.Verify] is automatically called
+// to check all expectations are met and no unexpected request has been
+// received.
+type MockServer struct {
+	t *td.T
+
+	mu           sync.Mutex
+	expectations []*MockExpectation
+	unexpected   []mockCall
+	verified     bool
+}
+
+// MockExpectation is an expectation of a [MockServer], as returned by
+// [MockServer.Expect]. Its methods allow to define the response sent
+// back when it matches and how many times it is expected to match.
+type MockExpectation struct {
+	t         *td.T
+	request   any
+	body      any
+	checkBody bool
+	location  location.Location
+	handler   http.HandlerFunc
+	min, max  int // max < 0 means no limit
+	calls     int
+}
+
+type mockCall struct {
+	request MockRequest
+	body    []byte
+}
+
+// NewMockServer returns a new [*MockServer] with no expectations.
+//
+// Starting go1.14, [MockServer.Verify] is automatically called at the
+// end of the test using tb.Cleanup(). For previous versions, it has
+// to be called explicitly.
+//
+// Note that tb can be a [*testing.T] as well as a [*td.T].
+func NewMockServer(tb testing.TB) *MockServer {
+	m := &MockServer{t: td.NewT(tb)}
+	cleanupTB(tb, m.Verify)
+	return m
+}
+
+// Expect registers a new expectation: when a request matching
+// expectedRequest and expectedBody is received, the response defined
+// by [MockExpectation.Respond] or [MockExpectation.RespondFunc] is
+// sent back.
+//
+// expectedRequest is compared against the [MockRequest]
+// representation of the received request, so it can be a
+// [MockRequest] or any [td.TestDeep] operator, typically [td.Struct]
+// or [td.SStruct]:
+//
+//	mock.Expect(td.Struct(tdhttp.MockRequest{Method: "POST", Path: "/users"}, nil),
+//	  td.JSON(`{"name": $1}`, td.HasPrefix("bob"))).
+//	  Respond(http.StatusCreated, map[string]any{"id": 12})
+//
+// expectedBody is optional. If omitted, the body is not checked. If it
+// is a string or a []byte (or an operator whose type behind is one of
+// these types), the raw body is compared against it. Otherwise, the
+// body is JSON unmarshaled in the type of expectedBody (or of the type
+// behind it) before being compared. If this type cannot be guessed, the
+// body is JSON unmarshaled as is, or compared as a string if it is not
+// JSON.
+//
+// Expectations are tried in the order they have been registered, the
+// first matching one being used. By default, an expectation must match
+// exactly once, see [MockExpectation.Times] and
+// [MockExpectation.AtLeast] to change this behavior. An expectation
+// that matched the maximum number of times it is expected is not tried
+// anymore.
+//
+// When no expectation matches, a 501 Not Implemented response is sent
+// back, and the request is reported as unexpected by [MockServer.Verify].
+func (m *MockServer) Expect(expectedRequest any, expectedBody ...any) *MockExpectation {
+	if len(expectedBody) > 1 {
+		m.t.Helper()
+		m.t.Fatal(color.TooManyParams("Expect(EXPECTED_REQUEST[, EXPECTED_BODY])"))
+	}
+
+	e := &MockExpectation{
+		t:       m.t,
+		request: expectedRequest,
+		min:     1,
+		max:     1,
+		handler: func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	}
+	if len(expectedBody) == 1 {
+		e.body = expectedBody[0]
+		e.checkBody = true
+	}
+	e.location, _ = location.New(1)
+
+	m.mu.Lock()
+	m.expectations = append(m.expectations, e)
+	m.mu.Unlock()
+	return e
+}
+
+// Respond sets the response sent back when e matches. body can be a
+// string or a []byte, sent as is, or any other type, sent JSON
+// encoded with "application/json" Content-Type. nil means no
+// body. headers are pairs of strings (header name followed by its
+// value) and/or [http.Header] instances.
+//
+//	mock.Expect(td.Struct(tdhttp.MockRequest{Method: "GET", Path: "/ping"}, nil)).
+//	  Respond(http.StatusOK, "pong", "Content-Type", "text/plain")
+func (e *MockExpectation) Respond(status int, body any, headers ...any) *MockExpectation {
+	header := http.Header{}
+	for i := 0; i < len(headers); i++ {
+		switch cur := headers[i].(type) {
+		case string:
+			i++
+			if i == len(headers) {
+				e.t.Helper()
+				e.t.Fatal(color.Bad("Respond: header %q has no value (@ headers[%d])", cur, i-1))
+			}
+			val, ok := headers[i].(string)
+			if !ok {
+				e.t.Helper()
+				e.t.Fatal(color.Bad(`Respond: header "%s" should have a string value, not a %T (@ headers[%d])`,
+					cur, headers[i], i))
+			}
+			header.Add(cur, val)
+		case http.Header:
+			for k, v := range cur {
+				k = http.CanonicalHeaderKey(k)
+				header[k] = append(header[k], v...)
+			}
+		default:
+			e.t.Helper()
+			e.t.Fatal(color.Bad("Respond: headers... can only contains string and http.Header, not %T (@ headers[%d])",
+				cur, i))
+		}
+	}
+
+	var rawBody []byte
+	switch b := body.(type) {
+	case nil:
+	case string:
+		rawBody = []byte(b)
+	case []byte:
+		rawBody = b
+	default:
+		var err error
+		rawBody, err = json.Marshal(body)
+		if err != nil {
+			e.t.Helper()
+			e.t.Fatal(color.Bad("Respond: JSON encoding failed: %s", err))
+		}
+		if header.Get("Content-Type") == "" {
+			header.Set("Content-Type", "application/json")
+		}
+	}
+
+	return e.RespondFunc(func(w http.ResponseWriter, _ *http.Request) {
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		w.Write(rawBody) //nolint: errcheck
+	})
+}
+
+// RespondFunc sets the handler called to send the response when e
+// matches.
+func (e *MockExpectation) RespondFunc(fn http.HandlerFunc) *MockExpectation {
+	e.handler = fn
+	return e
+}
+
+// Times sets the number of times e is expected to match to exactly n.
+func (e *MockExpectation) Times(n int) *MockExpectation {
+	e.min, e.max = n, n
+	return e
+}
+
+// AtLeast sets the minimum number of times e is expected to match to
+// n, without any maximum.
+func (e *MockExpectation) AtLeast(n int) *MockExpectation {
+	e.min, e.max = n, -1
+	return e
+}
+
+func (e *MockExpectation) exhausted() bool {
+	return e.max >= 0 && e.calls >= e.max
+}
+
+func (e *MockExpectation) String() string {
+	return fmt.Sprintf("expectation at %s:%d", e.location.File, e.location.Line)
+}
+
+// bodyValue returns body converted as specified by [MockServer.Expect]
+// to be compared against e.body.
+func (e *MockExpectation) bodyValue(body []byte) (any, error) {
+	var typ reflect.Type
+	if op, ok := e.body.(td.TestDeep); ok {
+		typ = op.TypeBehind()
+	} else {
+		typ = reflect.TypeOf(e.body)
+	}
+
+	switch {
+	case typ == nil || typ == types.Interface:
+		var v any
+		if json.Unmarshal(body, &v) != nil {
+			return string(body), nil
+		}
+		return v, nil
+
+	case typ.Kind() == reflect.String:
+		return reflect.ValueOf(string(body)).Convert(typ).Interface(), nil
+
+	case typ.Kind() == reflect.Slice && typ.Elem() == types.Uint8:
+		return reflect.ValueOf(body).Convert(typ).Interface(), nil
+	}
+
+	v := reflect.New(typ)
+	if err := json.Unmarshal(body, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
+
+func (e *MockExpectation) matchRequest(call mockCall) bool {
+	return td.EqDeeply(call.request, e.request)
+}
+
+func (e *MockExpectation) matchBody(call mockCall) bool {
+	if !e.checkBody {
+		return true
+	}
+	body, err := e.bodyValue(call.body)
+	return err == nil && td.EqDeeply(body, e.body)
+}
+
+// ServeHTTP implements [http.Handler] interface.
+func (m *MockServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	call := mockCall{
+		request: MockRequest{
+			Method: req.Method,
+			Host:   req.Host,
+			Path:   req.URL.Path,
+			Query:  req.URL.Query(),
+			Header: req.Header,
+		},
+	}
+	if req.Body != nil {
+		call.body, _ = ioutil.ReadAll(req.Body)
+		req.Body = ioutil.NopCloser(bytes.NewReader(call.body))
+	}
+	if call.request.Host == "" {
+		call.request.Host = req.URL.Host
+	}
+
+	m.mu.Lock()
+	var found *MockExpectation
+	for _, e := range m.expectations {
+		if !e.exhausted() && e.matchRequest(call) && e.matchBody(call) {
+			found = e
+			found.calls++
+			break
+		}
+	}
+	if found == nil {
+		m.unexpected = append(m.unexpected, call)
+	}
+	m.mu.Unlock()
+
+	if found == nil {
+		http.Error(w,
+			fmt.Sprintf("tdhttp.MockServer: no expectation matches %s %s", req.Method, req.URL),
+			http.StatusNotImplemented)
+		return
+	}
+	found.handler(w, req)
+}
+
+// RoundTrip implements [http.RoundTripper] interface, so m can be
+// used as the transport of an [http.Client]. See [MockServer.Client].
+func (m *MockServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		defer req.Body.Close() //nolint: errcheck
+	}
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// Client returns a new [*http.Client] using m as transport, so
+// requests never leave the process:
+//
+//	mock := tdhttp.NewMockServer(t)
+//	mock.Expect(td.Struct(tdhttp.MockRequest{Method: "GET", Path: "/ping"}, nil)).
+//	  Respond(http.StatusOK, "pong")
+//
+//	resp, err := mock.Client().Get("http://example.com/ping")
+func (m *MockServer) Client() *http.Client {
+	return &http.Client{Transport: m}
+}
+
+// nearest returns the expectation the closest to call, or nil if no
+// expectations are registered. An exhausted expectation fully matching
+// call is the closest, then an expectation only matching the request
+// but not its body, then the first registered one.
+func (m *MockServer) nearest(call mockCall) *MockExpectation {
+	if len(m.expectations) == 0 {
+		return nil
+	}
+	var requestOnly *MockExpectation
+	for _, e := range m.expectations {
+		if e.matchRequest(call) {
+			if e.matchBody(call) {
+				return e
+			}
+			if requestOnly == nil {
+				requestOnly = e
+			}
+		}
+	}
+	if requestOnly != nil {
+		return requestOnly
+	}
+	return m.expectations[0]
+}
+
+// Verify checks that all expectations have been met and that no
+// unexpected request has been received. Each unexpected request is
+// reported with the diff against its nearest expectation.
+//
+// Starting go1.14, it is automatically called at the end of the test,
+// see [NewMockServer]. It only reports once, subsequent calls do
+// nothing.
+func (m *MockServer) Verify() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.verified {
+		return
+	}
+	m.verified = true
+
+	t := m.t
+	t.Helper()
+
+	for _, call := range m.unexpected {
+		name := fmt.Sprintf("unexpected request %s %s", call.request.Method, call.request.Path)
+
+		e := m.nearest(call)
+		switch {
+		case e == nil:
+			t.Error(name + ", no expectations registered")
+
+		case e.matchRequest(call) && e.matchBody(call):
+			t.Errorf("%s: %s already matched %d times", name, e, e.calls)
+
+		case e.matchRequest(call):
+			body, err := e.bodyValue(call.body)
+			if err != nil {
+				t.Errorf("%s: body cannot be unmarshaled for nearest %s: %s", name, e, err)
+				break
+			}
+			t.RootName("Request.Body").
+				Cmp(body, e.body, name+", body differs from nearest "+e.String())
+
+		default:
+			t.RootName("Request").
+				Cmp(call.request, e.request, name+", nearest is "+e.String())
+		}
+	}
+
+	for _, e := range m.expectations {
+		if e.calls < e.min {
+			if e.max == e.min {
+				t.Errorf("%s matched %d times, but expected %d times", e, e.calls, e.min)
+			} else {
+				t.Errorf("%s matched %d times, but expected at least %d times", e, e.calls, e.min)
+			}
+		}
+	}
+}
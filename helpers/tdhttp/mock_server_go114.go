@@ -0,0 +1,16 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+//go:build go1.14
+// +build go1.14
+
+package tdhttp
+
+import "testing"
+
+func cleanupTB(tb testing.TB, finalize func()) {
+	tb.Cleanup(finalize)
+}
@@ -0,0 +1,16 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+//go:build !go1.14
+// +build !go1.14
+
+package tdhttp
+
+import "testing"
+
+// cleanupTB does nothing before go1.14, as testing.TB has no Cleanup
+// method.
+func cleanupTB(tb testing.TB, finalize func()) {}
@@ -0,0 +1,216 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/helpers/tdhttp"
+	"github.com/maxatome/go-testdeep/internal/test"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
+
+func doRequest(t *testing.T, client *http.Client, method, url, body string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close() //nolint: errcheck
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(b)
+}
+
+func TestMockServer(t *testing.T) {
+	t.Run("RoundTripper", func(t *testing.T) {
+		mock := tdhttp.NewMockServer(t)
+		mock.Expect(td.Struct(tdhttp.MockRequest{Method: "POST", Path: "/users"}, nil),
+			td.JSON(`{"name": $1}`, td.HasPrefix("bob"))).
+			Respond(http.StatusCreated, map[string]int{"id": 12}, "X-Id", "12")
+		mock.Expect(td.Struct(tdhttp.MockRequest{Method: "GET", Path: "/ping"}, nil)).
+			Respond(http.StatusOK, "pong").
+			AtLeast(2)
+		mock.Expect(td.Struct(
+			tdhttp.MockRequest{Method: "PUT"},
+			td.StructFields{"Query": td.ContainsKey("id")}),
+			td.Contains("xyz")).
+			RespondFunc(func(w http.ResponseWriter, req *http.Request) {
+				b, _ := ioutil.ReadAll(req.Body)
+				w.Write(b) //nolint: errcheck
+			}).
+			Times(2)
+
+		client := mock.Client()
+
+		status, body := doRequest(t, client, "POST", "http://example.com/users", `{"name":"bobby"}`)
+		td.Cmp(t, status, http.StatusCreated)
+		td.Cmp(t, body, `{"id":12}`)
+
+		for i := 0; i < 3; i++ {
+			status, body = doRequest(t, client, "GET", "http://example.com/ping", "")
+			td.Cmp(t, status, http.StatusOK)
+			td.Cmp(t, body, "pong")
+		}
+
+		for i := 0; i < 2; i++ {
+			status, body = doRequest(t, client, "PUT", "http://example.com/any?id=1", "--xyz--")
+			td.Cmp(t, status, http.StatusOK)
+			td.Cmp(t, body, "--xyz--")
+		}
+	})
+
+	t.Run("httptest.Server", func(t *testing.T) {
+		mock := tdhttp.NewMockServer(t)
+		mock.Expect(td.SStruct(tdhttp.MockRequest{Method: "GET", Path: "/ping"},
+			td.StructFields{
+				"Host":   td.Ignore(),
+				"Query":  td.Ignore(),
+				"Header": td.Ignore(),
+			})).
+			Respond(http.StatusOK, []byte("pong"))
+
+		srv := httptest.NewServer(mock)
+		defer srv.Close()
+
+		status, body := doRequest(t, srv.Client(), "GET", srv.URL+"/ping", "")
+		td.Cmp(t, status, http.StatusOK)
+		td.Cmp(t, body, "pong")
+	})
+
+	t.Run("Request body closed", func(t *testing.T) {
+		mock := tdhttp.NewMockServer(t)
+		mock.Expect(td.Struct(tdhttp.MockRequest{Method: "POST", Path: "/users"}, nil),
+			`{"name":"bob"}`)
+
+		body := &closeTracker{Reader: strings.NewReader(`{"name":"bob"}`)}
+		req, err := http.NewRequest("POST", "http://example.com/users", body)
+		td.Require(t).CmpNoError(err)
+
+		resp, err := mock.RoundTrip(req)
+		if td.CmpNoError(t, err) {
+			td.Cmp(t, resp.StatusCode, http.StatusOK)
+		}
+		td.CmpTrue(t, body.closed)
+	})
+
+	t.Run("Unexpected & unmet", func(t *testing.T) {
+		tb := test.NewTestingTB("test")
+		mock := tdhttp.NewMockServer(tb)
+		mock.Expect(td.Struct(tdhttp.MockRequest{Method: "POST", Path: "/users"}, nil),
+			td.JSON(`{"name": "bob"}`)).
+			Respond(http.StatusCreated, nil)
+		mock.Expect(td.Struct(tdhttp.MockRequest{Method: "GET", Path: "/ping"}, nil)).
+			Respond(http.StatusOK, "pong").
+			AtLeast(3)
+
+		client := mock.Client()
+
+		status, _ := doRequest(t, client, "POST", "http://example.com/users", `{"name":"bob"}`)
+		td.Cmp(t, status, http.StatusCreated)
+
+		// Exhausted expectation
+		status, body := doRequest(t, client, "POST", "http://example.com/users", `{"name":"bob"}`)
+		td.Cmp(t, status, http.StatusNotImplemented)
+		td.Cmp(t, body, "tdhttp.MockServer: no expectation matches POST http://example.com/users\n")
+
+		// Body mismatch
+		status, _ = doRequest(t, client, "POST", "http://example.com/users", `{"name":"max"}`)
+		td.Cmp(t, status, http.StatusNotImplemented)
+
+		// Request mismatch
+		status, _ = doRequest(t, client, "DELETE", "http://example.com/users", "")
+		td.Cmp(t, status, http.StatusNotImplemented)
+
+		doRequest(t, client, "GET", "http://example.com/ping", "")
+
+		mock.Verify()
+		test.EqualInt(t, len(tb.Messages), 4)
+		if len(tb.Messages) == 4 {
+			td.Cmp(t, tb.Messages[0], td.Re(
+				`^unexpected request POST /users: expectation at mock_server_test.go:\d+ already matched 1 times$`))
+			td.Cmp(t, tb.Messages[1], td.All(
+				td.HasPrefix("Failed test 'unexpected request POST /users, body differs from nearest expectation at mock_server_test.go:"),
+				td.Contains(`Request.Body["name"]: values differ`),
+				td.Contains(`"max"`),
+			))
+			td.Cmp(t, tb.Messages[2], td.All(
+				td.HasPrefix("Failed test 'unexpected request DELETE /users, nearest is expectation at mock_server_test.go:"),
+				td.Contains("Request.Method: values differ"),
+			))
+			td.Cmp(t, tb.Messages[3], td.Re(
+				`^expectation at mock_server_test.go:\d+ matched 1 times, but expected at least 3 times$`))
+		}
+
+		// Only once
+		tb.ResetMessages()
+		mock.Verify()
+		test.EqualInt(t, len(tb.Messages), 0)
+	})
+
+	t.Run("No expectations", func(t *testing.T) {
+		tb := test.NewTestingTB("test")
+		mock := tdhttp.NewMockServer(tb)
+
+		status, _ := doRequest(t, mock.Client(), "GET", "http://example.com/", "")
+		td.Cmp(t, status, http.StatusNotImplemented)
+
+		mock.Verify()
+		td.Cmp(t, tb.Messages, []string{"unexpected request GET /, no expectations registered"})
+	})
+
+	t.Run("Exact times", func(t *testing.T) {
+		tb := test.NewTestingTB("test")
+		mock := tdhttp.NewMockServer(tb)
+		mock.Expect(tdhttp.MockRequest{Method: "GET", Path: "/"}).Times(2)
+
+		mock.Verify()
+		td.Cmp(t, tb.Messages, td.Bag(
+			td.Re(`^expectation at mock_server_test.go:\d+ matched 0 times, but expected 2 times$`),
+		))
+	})
+
+	t.Run("Bad usage", func(t *testing.T) {
+		tb := test.NewTestingTB("test")
+		mock := tdhttp.NewMockServer(tb)
+		td.CmpContains(t, tb.CatchFatal(func() { mock.Expect(td.Ignore(), 1, 2) }),
+			"usage: Expect(EXPECTED_REQUEST[, EXPECTED_BODY]), too many parameters")
+
+		e := mock.Expect(td.Ignore())
+		td.CmpContains(t, tb.CatchFatal(func() { e.Respond(200, nil, "X-Foo") }),
+			`Respond: header "X-Foo" has no value (@ headers[0])`)
+		td.CmpContains(t, tb.CatchFatal(func() { e.Respond(200, nil, "X-Foo", 12) }),
+			`Respond: header "X-Foo" should have a string value, not a int (@ headers[1])`)
+		td.CmpContains(t, tb.CatchFatal(func() { e.Respond(200, nil, 12) }),
+			"Respond: headers... can only contains string and http.Header, not int (@ headers[0])")
+		td.CmpContains(t, tb.CatchFatal(func() { e.Respond(200, func() {}) }),
+			"Respond: JSON encoding failed: ")
+	})
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+
+	"github.com/maxatome/go-testdeep/internal/color"
+)
+
+// session holds the state shared by all the requests sent by a
+// [TestAPI] instance. See [TestAPI.WithCookieJar],
+// [TestAPI.WithDefaultHeader] & co.
+type session struct {
+	jar    *cookieJar
+	header http.Header
+	query  url.Values
+}
+
+// copy returns a deep copy of s, so a subtest can alter its session
+// without affecting its parent one.
+func (s session) copy() session {
+	if s.jar != nil {
+		s.jar = s.jar.copy()
+	}
+	if s.header != nil {
+		header := make(http.Header, len(s.header))
+		for k, v := range s.header {
+			header[k] = append([]string(nil), v...)
+		}
+		s.header = header
+	}
+	if s.query != nil {
+		query := make(url.Values, len(s.query))
+		for k, v := range s.query {
+			query[k] = append([]string(nil), v...)
+		}
+		s.query = query
+	}
+	return s
+}
+
+// apply adds default headers, base query parameters and cookies of
+// the jar to req, unless req already defines them.
+func (s session) apply(req *http.Request) {
+	for k, v := range s.header {
+		if _, exists := req.Header[k]; !exists {
+			req.Header[k] = append([]string(nil), v...)
+		}
+	}
+
+	if len(s.query) > 0 {
+		qp := req.URL.Query()
+		for k, v := range s.query {
+			if _, exists := qp[k]; !exists {
+				qp[k] = v
+			}
+		}
+		req.URL.RawQuery = qp.Encode()
+		if req.RequestURI != "" {
+			req.RequestURI = req.URL.RequestURI()
+		}
+	}
+
+	if s.jar != nil {
+		for _, c := range s.jar.Cookies(cookieURL(req)) {
+			if _, err := req.Cookie(c.Name); err != nil {
+				req.AddCookie(c)
+			}
+		}
+	}
+}
+
+// storeCookies stores in the jar, if any, the cookies set by resp,
+// the response of req.
+func (s session) storeCookies(req *http.Request, resp *http.Response) {
+	if s.jar != nil {
+		if cookies := resp.Cookies(); len(cookies) > 0 {
+			s.jar.SetCookies(cookieURL(req), cookies)
+		}
+	}
+}
+
+// cookieURL returns the absolute URL of req, as needed by cookie
+// jars. Requests sent to a handler have a relative URL, so the host
+// is taken from req.Host.
+func cookieURL(req *http.Request) *url.URL {
+	if req.URL.IsAbs() {
+		return req.URL
+	}
+	u := *req.URL
+	u.Scheme = "http"
+	if req.TLS != nil {
+		u.Scheme = "https"
+	}
+	u.Host = req.Host
+	return &u
+}
+
+type jarEntry struct {
+	u       *url.URL
+	cookies []*http.Cookie
+}
+
+// cookieJar is a [cookiejar.Jar] remembering all the stored cookies
+// so it can be copied, as [cookiejar.Jar] does not allow to list
+// them.
+type cookieJar struct {
+	*cookiejar.Jar
+	entries []jarEntry
+}
+
+func newCookieJar() *cookieJar {
+	jar, _ := cookiejar.New(nil) // never fails
+	return &cookieJar{Jar: jar}
+}
+
+// SetCookies implements [http.CookieJar] interface.
+func (j *cookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.Jar.SetCookies(u, cookies)
+	j.entries = append(j.entries, jarEntry{u: u, cookies: cookies})
+}
+
+// copy returns a new jar with the same cookies as j.
+func (j *cookieJar) copy() *cookieJar {
+	nj := newCookieJar()
+	for _, e := range j.entries {
+		nj.SetCookies(e.u, e.cookies)
+	}
+	return nj
+}
+
+// WithCookieJar enables a cookie jar for the following requests: the
+// cookies set by responses are automatically stored and sent back
+// in the following requests, as a browser would do. Cookies
+// explicitly passed to a request take precedence over the stored
+// ones. Calling it again starts a new empty jar.
+//
+//	ta := tdhttp.NewTestAPI(t, mux).WithCookieJar()
+//
+//	ta.PostForm("/login", url.Values{"user": {"bob"}, "pass": {"secret"}}).
+//	  CmpStatus(200)
+//
+//	// The session cookie set by /login is sent back
+//	ta.Get("/profile").
+//	  CmpStatus(200)
+//
+// Subtests run by [TestAPI.Run] or created by [TestAPI.With]
+// inherit a copy of the jar.
+func (t *TestAPI) WithCookieJar() *TestAPI {
+	t.session.jar = newCookieJar()
+	return t
+}
+
+// WithDefaultHeader sets the header key to value for all the
+// following requests. A header explicitly passed to a request takes
+// precedence over the default one.
+//
+//	ta.WithDefaultHeader("X-Api-Version", "2")
+//
+// Subtests run by [TestAPI.Run] or created by [TestAPI.With]
+// inherit a copy of the default headers.
+func (t *TestAPI) WithDefaultHeader(key, value string) *TestAPI {
+	if t.session.header == nil {
+		t.session.header = http.Header{}
+	}
+	t.session.header.Set(key, value)
+	return t
+}
+
+// WithBearerToken sets the Authorization header of all the following
+// requests to "Bearer token". It is a shortcut for:
+//
+//	ta.WithDefaultHeader("Authorization", "Bearer "+token)
+//
+// See [TestAPI.WithDefaultHeader].
+func (t *TestAPI) WithBearerToken(token string) *TestAPI {
+	return t.WithDefaultHeader("Authorization", "Bearer "+token)
+}
+
+// WithBasicAuth sets the Authorization header of all the following
+// requests for HTTP Basic Authentication using user and password. See
+// [BasicAuthHeader] and [TestAPI.WithDefaultHeader].
+func (t *TestAPI) WithBasicAuth(user, password string) *TestAPI {
+	for k, v := range BasicAuthHeader(user, password) {
+		t.WithDefaultHeader(k, v[0])
+	}
+	return t
+}
+
+// WithBaseQuery adds query to the query parameters of all the
+// following requests. A parameter explicitly passed to a request, in
+// its target or using headersQueryParams, takes precedence over the
+// base one.
+//
+//	ta.WithBaseQuery(tdhttp.Q{"lang": "fr", "dryrun": true})
+//
+// Calling it several times accumulates the parameters. Subtests run
+// by [TestAPI.Run] or created by [TestAPI.With] inherit a copy of
+// the base query parameters.
+func (t *TestAPI) WithBaseQuery(query Q) *TestAPI {
+	if t.session.query == nil {
+		t.session.query = url.Values{}
+	}
+	if err := query.AddTo(t.session.query); err != nil {
+		t.t.Helper()
+		t.t.Fatal(color.Bad("WithBaseQuery: %s", err))
+	}
+	return t
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/helpers/tdhttp"
+	"github.com/maxatome/go-testdeep/helpers/tdutil"
+	"github.com/maxatome/go-testdeep/internal/test"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func sessionServer() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/login", func(w http.ResponseWriter, req *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: req.URL.Query().Get("user")})
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, req *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", MaxAge: -1})
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, req *http.Request) {
+		cookies := map[string]string{}
+		for _, c := range req.Cookies() {
+			cookies[c.Name] = c.Value
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{ //nolint: errcheck
+			"cookies": cookies,
+			"auth":    req.Header.Get("Authorization"),
+			"version": req.Header.Get("X-Api-Version"),
+			"query":   req.URL.Query(),
+		})
+	})
+
+	return mux
+}
+
+func TestSession(t *testing.T) {
+	mux := sessionServer()
+
+	t.Run("Cookie jar", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(tdutil.NewT("test"), mux)
+
+		// No jar
+		ta.Get("/login", tdhttp.Q{"user": "bob"}).CmpStatus(204)
+		td.CmpFalse(t, ta.Get("/echo").
+			CmpJSONBody(td.SuperMapOf(map[string]any{"cookies": td.Empty()}, nil)).
+			Failed())
+
+		td.Cmp(t, ta.WithCookieJar(), td.Shallow(ta))
+		ta.Get("/login", tdhttp.Q{"user": "bob"}).CmpStatus(204)
+		td.CmpFalse(t, ta.Get("/echo").
+			CmpJSONBody(td.SuperMapOf(map[string]any{
+				"cookies": map[string]any{"session": "bob"},
+			}, nil)).
+			Failed())
+
+		// Explicit cookies take precedence
+		td.CmpFalse(t, ta.Get("/echo", &http.Cookie{Name: "session", Value: "max"}).
+			CmpJSONBody(td.SuperMapOf(map[string]any{
+				"cookies": map[string]any{"session": "max"},
+			}, nil)).
+			Failed())
+
+		// Subtests inherit a copy of the jar
+		td.CmpTrue(t, ta.Run("sub", func(ta *tdhttp.TestAPI) {
+			td.CmpFalse(t, ta.Get("/echo").
+				CmpJSONBody(td.SuperMapOf(map[string]any{
+					"cookies": map[string]any{"session": "bob"},
+				}, nil)).
+				Failed())
+
+			ta.Get("/logout").CmpStatus(204)
+			td.CmpFalse(t, ta.Get("/echo").
+				CmpJSONBody(td.SuperMapOf(map[string]any{"cookies": td.Empty()}, nil)).
+				Failed())
+		}))
+		td.CmpFalse(t, ta.Get("/echo").
+			CmpJSONBody(td.SuperMapOf(map[string]any{
+				"cookies": map[string]any{"session": "bob"},
+			}, nil)).
+			Failed())
+
+		sub := ta.With(tdutil.NewT("test2"))
+		sub.Get("/login", tdhttp.Q{"user": "alice"}).CmpStatus(204)
+		td.CmpFalse(t, sub.Get("/echo").
+			CmpJSONBody(td.SuperMapOf(map[string]any{
+				"cookies": map[string]any{"session": "alice"},
+			}, nil)).
+			Failed())
+		td.CmpFalse(t, ta.Get("/echo").
+			CmpJSONBody(td.SuperMapOf(map[string]any{
+				"cookies": map[string]any{"session": "bob"},
+			}, nil)).
+			Failed())
+
+		// A new jar is empty
+		ta.WithCookieJar()
+		td.CmpFalse(t, ta.Get("/echo").
+			CmpJSONBody(td.SuperMapOf(map[string]any{"cookies": td.Empty()}, nil)).
+			Failed())
+	})
+
+	t.Run("Cookie jar with client", func(t *testing.T) {
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		ta := tdhttp.NewTestAPIClient(tdutil.NewT("test"), srv.URL, nil).
+			WithCookieJar()
+		ta.Get("/login", tdhttp.Q{"user": "bob"}).CmpStatus(204)
+		td.CmpFalse(t, ta.Get("/echo").
+			CmpJSONBody(td.SuperMapOf(map[string]any{
+				"cookies": map[string]any{"session": "bob"},
+			}, nil)).
+			Failed())
+	})
+
+	t.Run("Default headers", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(tdutil.NewT("test"), mux).
+			WithDefaultHeader("X-Api-Version", "2").
+			WithBearerToken("tok3n")
+
+		td.CmpFalse(t, ta.Get("/echo").
+			CmpJSONBody(td.SuperMapOf(map[string]any{
+				"auth":    "Bearer tok3n",
+				"version": "2",
+			}, nil)).
+			Failed())
+
+		// Explicit headers take precedence
+		td.CmpFalse(t, ta.Get("/echo", "X-Api-Version", "3").
+			CmpJSONBody(td.SuperMapOf(map[string]any{
+				"auth":    "Bearer tok3n",
+				"version": "3",
+			}, nil)).
+			Failed())
+
+		// Subtests inherit a copy of the default headers
+		td.CmpTrue(t, ta.Run("sub", func(ta *tdhttp.TestAPI) {
+			td.CmpFalse(t, ta.WithBasicAuth("max", "5ecr3T").Get("/echo").
+				CmpJSONBody(td.SuperMapOf(map[string]any{
+					"auth":    "Basic bWF4OjVlY3IzVA==",
+					"version": "2",
+				}, nil)).
+				Failed())
+		}))
+		td.CmpFalse(t, ta.Get("/echo").
+			CmpJSONBody(td.SuperMapOf(map[string]any{
+				"auth": "Bearer tok3n",
+			}, nil)).
+			Failed())
+	})
+
+	t.Run("Base query", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(tdutil.NewT("test"), mux).
+			WithBaseQuery(tdhttp.Q{"lang": "fr", "dryrun": true})
+
+		td.CmpFalse(t, ta.Get("/echo").
+			CmpJSONBody(td.SuperMapOf(map[string]any{
+				"query": map[string]any{"lang": []any{"fr"}, "dryrun": []any{"true"}},
+			}, nil)).
+			Failed())
+
+		// Explicit parameters take precedence
+		td.CmpFalse(t, ta.Get("/echo?lang=en", tdhttp.Q{"id": 12}).
+			CmpJSONBody(td.SuperMapOf(map[string]any{
+				"query": map[string]any{
+					"lang":   []any{"en"},
+					"dryrun": []any{"true"},
+					"id":     []any{"12"},
+				},
+			}, nil)).
+			Failed())
+
+		// Subtests inherit a copy of the base query
+		td.CmpTrue(t, ta.Run("sub", func(ta *tdhttp.TestAPI) {
+			td.CmpFalse(t, ta.WithBaseQuery(tdhttp.Q{"page": 2}).Get("/echo").
+				CmpJSONBody(td.SuperMapOf(map[string]any{
+					"query": map[string]any{
+						"lang":   []any{"fr"},
+						"dryrun": []any{"true"},
+						"page":   []any{"2"},
+					},
+				}, nil)).
+				Failed())
+		}))
+		td.CmpFalse(t, ta.Get("/echo").
+			CmpJSONBody(td.SuperMapOf(map[string]any{
+				"query": td.Len(2),
+			}, nil)).
+			Failed())
+
+		tb := test.NewTestingTB("test")
+		ta = tdhttp.NewTestAPI(tb, mux)
+		td.CmpHasPrefix(t,
+			tb.CatchFatal(func() { ta.WithBaseQuery(tdhttp.Q{"bad": func() {}}) }),
+			"WithBaseQuery: ")
+	})
+}
@@ -0,0 +1,313 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maxatome/go-testdeep/internal/color"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// SSEEvent is a server-sent event, as parsed by [TestAPI.CmpSSE].
+type SSEEvent struct {
+	ID    string
+	Event string
+	// Data contains all the data lines of the event, joined by "\n".
+	Data  string
+	Retry time.Duration
+	// JSON is Data once JSON unmarshaled, or nil if Data is not
+	// valid JSON.
+	JSON any
+}
+
+var sseLineEndings = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// splitSSELine splits line into its field name and value. A comment
+// line returns an empty field name.
+func splitSSELine(line string) (field, value string) {
+	if colon := strings.IndexByte(line, ':'); colon >= 0 {
+		return line[:colon], strings.TrimPrefix(line[colon+1:], " ")
+	}
+	return line, ""
+}
+
+// isSSEField returns true if line contains a known field, so is part
+// of an event.
+func isSSEField(line string) bool {
+	field, value := splitSSELine(line)
+	switch field {
+	case "id", "event", "data":
+		return true
+	case "retry":
+		_, err := strconv.ParseUint(value, 10, 63)
+		return err == nil
+	}
+	return false
+}
+
+// parseSSE parses body as a text/event-stream and returns the events
+// it contains. Contrary to browsers, an event is returned for each
+// block containing at least one field, even if it has no data, and
+// its ID is not inherited from the previous events.
+func parseSSE(body []byte) []SSEEvent {
+	var (
+		events  []SSEEvent
+		cur     SSEEvent
+		pending bool
+		data    []string
+	)
+
+	dispatch := func() {
+		if pending {
+			cur.Data = strings.Join(data, "\n")
+			if cur.Data != "" {
+				var v any
+				if json.Unmarshal([]byte(cur.Data), &v) == nil {
+					cur.JSON = v
+				}
+			}
+			events = append(events, cur)
+		}
+		cur, pending, data = SSEEvent{}, false, nil
+	}
+
+	for _, line := range strings.Split(sseLineEndings.Replace(string(body)), "\n") {
+		if line == "" {
+			dispatch()
+			continue
+		}
+		field, value := splitSSELine(line)
+
+		switch field {
+		case "id":
+			cur.ID = value
+		case "event":
+			cur.Event = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			ms, err := strconv.ParseUint(value, 10, 63)
+			if err != nil {
+				continue
+			}
+			cur.Retry = time.Duration(ms) * time.Millisecond
+		default:
+			continue
+		}
+		pending = true
+	}
+	// An incomplete final event is discarded, as browsers do
+	return events
+}
+
+// CmpSSE tests that the last request response body is a stream of
+// server-sent events (aka text/event-stream) matching expectedEvents,
+// in order and with no extra events.
+//
+// Each item of expectedEvents is compared against the corresponding
+// [SSEEvent] and can be an [SSEEvent] or a [td.TestDeep] operator. As
+// a special case, an [SSEEvent] with a nil JSON field does not check
+// the JSON field of the received event. The JSON field allows to use
+// JSON aware operators on data:
+//
+//	ta.Get("/notifications").
+//	  CmpStatus(http.StatusOK).
+//	  CmpHeader(td.ContainsKey("Content-Type")).
+//	  CmpSSE(
+//	    tdhttp.SSEEvent{Event: "hello", Data: "world"},
+//	    td.Struct(tdhttp.SSEEvent{Event: "user"},
+//	      td.StructFields{"JSON": td.JSON(`{"name": "Bob", "id": $1}`, td.NotZero())}),
+//	    td.Struct(tdhttp.SSEEvent{}, td.StructFields{"Retry": td.Gt(time.Second)}),
+//	  )
+//
+// Multi-line data are joined using "\n". Comments and unknown fields
+// are ignored, as well as an incomplete final event.
+//
+// As [TestAPI.Request] & co. wait for the handler to return, they
+// cannot be used to test infinite streams. See [TestAPI.GetSSE] and
+// [TestAPI.RequestSSE] for this case.
+//
+// It fails if no request has been sent yet.
+func (t *TestAPI) CmpSSE(expectedEvents ...any) *TestAPI {
+	t.t.Helper()
+
+	entries := make(td.ArrayEntries, len(expectedEvents))
+	for i, expected := range expectedEvents {
+		if ev, ok := expected.(SSEEvent); ok && ev.JSON == nil {
+			expected = td.SStruct(ev, td.StructFields{"JSON": td.Ignore()})
+		}
+		entries[i] = expected
+	}
+
+	return t.cmpMarshaledBody(
+		true, // accept empty body, as a stream without events
+		func(body []byte, target any) error {
+			*target.(*[]SSEEvent) = parseSSE(body)
+			return nil
+		},
+		td.Slice([]SSEEvent{}, entries))
+}
+
+// GetSSE sends a HTTP GET to the tested API and reads the response
+// as a stream of server-sent events, stopping after maxEvents events
+// or when timeout is reached, whichever comes first. It allows to
+// test infinite streams:
+//
+//	ta.GetSSE("/notifications", 2, time.Second).
+//	  CmpStatus(http.StatusOK).
+//	  CmpSSE(
+//	    tdhttp.SSEEvent{Event: "tick", Data: "1"},
+//	    tdhttp.SSEEvent{Event: "tick", Data: "2"},
+//	  )
+//
+// See [TestAPI.RequestSSE] for details.
+//
+// See [NewRequest] for all possible formats accepted in headersQueryParams.
+func (t *TestAPI) GetSSE(target string, maxEvents int, timeout time.Duration, headersQueryParams ...any) *TestAPI {
+	req, err := get(target, headersQueryParams...)
+	if err != nil {
+		t.t.Helper()
+		t.t.Fatal(err)
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	t.t.Helper()
+	return t.RequestSSE(req, maxEvents, timeout)
+}
+
+// RequestSSE sends a new HTTP request to the tested API and reads
+// the response as a stream of server-sent events, stopping after
+// maxEvents events (if maxEvents > 0), when timeout is reached (if
+// timeout > 0) or when req context is done, whichever comes
+// first. The connection is then closed. As an infinite stream would
+// block forever, at least one of these limits is required:
+// RequestSSE fails if maxEvents <= 0, timeout <= 0 and req context
+// can never be done, as for a request created by [NewRequest].
+//
+// The response body only contains the events read, so
+// [TestAPI.CmpSSE] can then be used to check them, as well as
+// [TestAPI.CmpStatus] or [TestAPI.CmpHeader]. Reaching timeout is not
+// a failure: CmpSSE reports the missing events if any. As for
+// [TestAPI.Request], a stream encoded using gzip and/or deflate is
+// transparently decoded, while being read.
+//
+// Contrary to [TestAPI.Request], the handler of a [TestAPI] created
+// by [NewTestAPI] is served by an [httptest.Server] started for the
+// request, so the events are received as soon as they are
+// flushed. Once the connection closed, the handler should return as
+// soon as the request context is done, as RequestSSE waits for it
+// before shutting down the server and returning.
+//
+// Note that [TestAPI.Failed] status is reset just after this call.
+func (t *TestAPI) RequestSSE(req *http.Request, maxEvents int, timeout time.Duration) *TestAPI {
+	t.t.Helper()
+
+	if maxEvents <= 0 && timeout <= 0 && req.Context().Done() == nil {
+		t.t.Fatal(color.Bad("RequestSSE: maxEvents, timeout or a cancelable request context is required"))
+	}
+
+	t.resetResponse()
+
+	client, baseURL := t.client, t.baseURL
+	if client == nil {
+		srv := httptest.NewServer(t.handler)
+		defer func() {
+			// Cancel the request context of a handler still running
+			srv.CloseClientConnections()
+			srv.Close()
+		}()
+
+		client = srv.Client()
+		baseURL, _ = url.Parse(srv.URL) // never fails
+	}
+	t.prepareClientRequest(req, baseURL)
+
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(req.Context(), timeout)
+	} else {
+		ctx, cancel = context.WithCancel(req.Context())
+	}
+	defer cancel()
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		t.t.Fatal(color.Bad("%s %s request failed: %s", req.Method, req.URL, err))
+	}
+	defer resp.Body.Close() //nolint: errcheck
+
+	for k, v := range resp.Header {
+		t.response.Header()[k] = v
+	}
+	t.response.WriteHeader(resp.StatusCode)
+
+	var body io.Reader = resp.Body
+	contentEncoding := resp.Header.Get("Content-Encoding")
+	if decodableContentEncoding(contentEncoding) {
+		t.response.Header().Del("Content-Length")
+		body, err = contentDecoder(resp.Body, contentEncoding)
+		if err != nil {
+			if ctx.Err() == nil {
+				t.bodyDecodingFailed(contentEncoding, err)
+			}
+			body = strings.NewReader("")
+		}
+	}
+
+	err = readSSE(t.response.Body, body, maxEvents)
+	if err != nil && ctx.Err() == nil {
+		t.t.Fatal(color.Bad("%s %s response body cannot be read: %s", req.Method, req.URL, err))
+	}
+
+	t.session.storeCookies(req, t.response.Result())
+
+	return t
+}
+
+// readSSE copies the server-sent events read from r to w, until
+// maxEvents events are read (if maxEvents > 0) or the end of r. It
+// never returns io.EOF.
+func readSSE(w *bytes.Buffer, r io.Reader, maxEvents int) error {
+	br := bufio.NewReader(r)
+	num, pending := 0, false
+	for {
+		line, err := br.ReadString('\n')
+		w.WriteString(line)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			pending = pending || isSSEField(line)
+		} else if pending {
+			pending = false
+			num++
+			if maxEvents > 0 && num >= maxEvents {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp_test
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/helpers/tdhttp"
+	"github.com/maxatome/go-testdeep/helpers/tdutil"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+func sseServer() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/events", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": comment\r\n"+
+			"event: hello\r\n"+
+			"data: world\r\n"+
+			"\r\n"+
+			"id: 12\n"+
+			"event: user\n"+
+			`data: {"name": "Bob",`+"\n"+
+			`data:  "id": 42}`+"\n"+
+			"unknown: field\n"+
+			"\n"+
+			"retry: 1500\n"+
+			"\n"+
+			"retry: bad\n"+
+			"\n"+
+			"data\n"+
+			"\n"+
+			"data: incomplete")
+	})
+
+	mux.HandleFunc("/ticks", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		if req.Header.Get("Accept") != "text/event-stream" {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		flusher := w.(http.Flusher)
+		for i := 1; ; i++ {
+			fmt.Fprintf(w, "event: tick\ndata: %d\n\n", i)
+			flusher.Flush()
+
+			select {
+			case <-req.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	})
+
+	mux.HandleFunc("/gzip-ticks", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close() //nolint: errcheck
+		for i := 1; ; i++ {
+			fmt.Fprintf(gz, "event: tick\ndata: %d\n\n", i)
+			gz.Flush() //nolint: errcheck
+			w.(http.Flusher).Flush()
+
+			select {
+			case <-req.Context().Done():
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	})
+
+	mux.HandleFunc("/bad-gzip", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Content-Encoding", "gzip")
+		fmt.Fprint(w, "data: not gzip\n\n")
+	})
+
+	mux.HandleFunc("/slow-ticks", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		fmt.Fprint(w, "data: first\n\n")
+		w.(http.Flusher).Flush()
+		<-req.Context().Done()
+	})
+
+	return mux
+}
+
+func TestCmpSSE(t *testing.T) {
+	mux := sseServer()
+
+	t.Run("OK", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(t, mux)
+
+		ta.Get("/events").
+			CmpStatus(http.StatusOK).
+			CmpSSE(
+				tdhttp.SSEEvent{Event: "hello", Data: "world"},
+				td.Struct(tdhttp.SSEEvent{ID: "12", Event: "user"},
+					td.StructFields{"JSON": td.JSON(`{"name": "Bob", "id": $1}`, td.NotZero())}),
+				tdhttp.SSEEvent{Retry: 1500 * time.Millisecond},
+				tdhttp.SSEEvent{},
+			)
+		td.CmpFalse(t, ta.Failed())
+
+		ta.Get("/events").
+			CmpSSE(
+				td.Ignore(),
+				tdhttp.SSEEvent{ID: "12", Event: "user", Data: "{\"name\": \"Bob\",\n \"id\": 42}"},
+				td.Ignore(),
+				td.Ignore(),
+			)
+		td.CmpFalse(t, ta.Failed())
+
+		// Empty body
+		ta.Get("/ticks").CmpStatus(http.StatusNotAcceptable).CmpSSE()
+		td.CmpFalse(t, ta.Failed())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		ta := tdhttp.NewTestAPI(tdutil.NewT("test"), mux)
+
+		td.CmpTrue(t, ta.CmpSSE().Failed(), "no request sent")
+
+		td.CmpTrue(t, ta.Get("/events").CmpSSE(tdhttp.SSEEvent{Event: "hello", Data: "world"}).Failed(),
+			"missing events")
+
+		td.CmpTrue(t, ta.Get("/events").
+			CmpSSE(
+				tdhttp.SSEEvent{Event: "hello", Data: "world!"},
+				td.Ignore(),
+				td.Ignore(),
+				td.Ignore(),
+			).
+			Failed())
+
+		td.CmpTrue(t, ta.GetSSE("/bad-gzip", 1, 5*time.Second, "Accept-Encoding", "gzip").Failed(),
+			"corrupted stream")
+	})
+}
+
+func TestGetSSE(t *testing.T) {
+	mux := sseServer()
+
+	check := func(t *testing.T, ta *tdhttp.TestAPI) {
+		t.Helper()
+
+		ta.GetSSE("/ticks", 3, 5*time.Second).
+			CmpStatus(http.StatusOK).
+			CmpHeader(td.SuperMapOf(http.Header{"Content-Type": {"text/event-stream"}}, nil)).
+			CmpSSE(
+				tdhttp.SSEEvent{Event: "tick", Data: "1"},
+				tdhttp.SSEEvent{Event: "tick", Data: "2"},
+				tdhttp.SSEEvent{Event: "tick", Data: "3"},
+			)
+		td.CmpFalse(t, ta.Failed())
+
+		// Timeout
+		ta.GetSSE("/slow-ticks", 3, 100*time.Millisecond).
+			CmpStatus(http.StatusOK).
+			CmpSSE(tdhttp.SSEEvent{Data: "first"})
+		td.CmpFalse(t, ta.Failed())
+
+		// Compressed stream
+		ta.GetSSE("/gzip-ticks", 2, 5*time.Second, "Accept-Encoding", "gzip").
+			CmpStatus(http.StatusOK).
+			CmpContentEncoding("gzip").
+			CmpSSE(
+				tdhttp.SSEEvent{Event: "tick", Data: "1"},
+				tdhttp.SSEEvent{Event: "tick", Data: "2"},
+			)
+		td.CmpFalse(t, ta.Failed())
+
+		// Explicit Accept header
+		ta.GetSSE("/ticks", 1, 5*time.Second, "Accept", "text/plain").
+			CmpStatus(http.StatusNotAcceptable).
+			CmpSSE()
+		td.CmpFalse(t, ta.Failed())
+
+		// Until the end of the stream
+		ta.RequestSSE(tdhttp.NewRequest("GET", "/events", nil), 0, 5*time.Second).
+			CmpSSE(td.Ignore(), td.Ignore(), td.Ignore(), td.Ignore())
+		td.CmpFalse(t, ta.Failed())
+
+		// Until the request context is done
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		ta.RequestSSE(tdhttp.NewRequest("GET", "/slow-ticks", nil).WithContext(ctx), 0, 0).
+			CmpStatus(http.StatusOK).
+			CmpSSE(tdhttp.SSEEvent{Data: "first"})
+		td.CmpFalse(t, ta.Failed())
+	}
+
+	t.Run("Handler", func(t *testing.T) {
+		check(t, tdhttp.NewTestAPI(t, mux))
+	})
+
+	t.Run("Client", func(t *testing.T) {
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		check(t, tdhttp.NewTestAPIClient(t, srv.URL, nil))
+	})
+
+	t.Run("No limit", func(t *testing.T) {
+		tt := tdutil.NewT("test")
+		ta := tdhttp.NewTestAPI(tt, mux)
+
+		td.CmpTrue(t, tt.CatchFailNow(func() {
+			ta.RequestSSE(tdhttp.NewRequest("GET", "/ticks", nil), 0, 0)
+		}))
+	})
+}
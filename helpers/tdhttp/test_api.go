@@ -7,10 +7,12 @@
 package tdhttp
 
 import (
+	"bytes"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -28,13 +30,23 @@ import (
 	"github.com/maxatome/go-testdeep/td"
 )
 
-// TestAPI allows to test one HTTP API. See [NewTestAPI] function to
-// create a new instance and get some examples of use.
+// TestAPI allows to test one HTTP API. See [NewTestAPI] and
+// [NewTestAPIClient] functions to create a new instance and get some
+// examples of use.
 type TestAPI struct {
 	t       *td.T
 	handler http.Handler
 	name    string
 
+	// client and baseURL are set when requests are sent to a live
+	// server, see NewTestAPIClient
+	client  *http.Client
+	baseURL *url.URL
+
+	// session contains the state shared by all requests, see
+	// WithCookieJar, WithDefaultHeader & co.
+	session session
+
 	sentAt        time.Time
 	response      *httptest.ResponseRecorder
 	statusFailed  bool
@@ -42,6 +54,15 @@ type TestAPI struct {
 	cookiesFailed bool
 	bodyFailed    bool
 
+	// graphQLErrorsExpected is set by CmpGraphQLErrors, so
+	// CmpGraphQLData accepts a response containing errors.
+	graphQLErrorsExpected bool
+
+	// jsonRPC records the JSON-RPC calls sent by the last request,
+	// see CallJSONRPC & co. jsonRPCID is the last call id used.
+	jsonRPC   *jsonRPCState
+	jsonRPCID int64
+
 	// autoDumpResponse dumps the received response when a test fails.
 	autoDumpResponse bool
 	responseDumped   bool
@@ -75,10 +96,70 @@ func NewTestAPI(tb testing.TB, handler http.Handler) *TestAPI {
 	}
 }
 
+// NewTestAPIClient creates a [TestAPI] that can be used to test routes
+// of the API served at baseURL, requests being sent using client. If
+// client is nil, [http.DefaultClient] is used.
+//
+// Contrary to [NewTestAPI], requests go through the network, so the
+// whole server stack is tested, including TLS, middlewares and
+// timeouts as configured in client:
+//
+//	srv := httptest.NewTLSServer(mux)
+//	defer srv.Close()
+//
+//	ta := tdhttp.NewTestAPIClient(t, srv.URL, srv.Client())
+//
+//	ta.Get("/test").
+//	  CmpStatus(200).
+//	  CmpBody("OK!")
+//
+// Targets of requests are relative to baseURL, so with baseURL set to
+// "http://localhost:8080/api", ta.Get("/test") requests
+// "http://localhost:8080/api/test". Absolute targets are used as is.
+//
+// Note that redirections are followed depending on client
+// CheckRedirect field, as usual. If the request cannot be sent or its
+// response cannot be read, the test fails and stops at once.
+//
+// Note that tb can be a [*testing.T] as well as a [*td.T].
+func NewTestAPIClient(tb testing.TB, baseURL string, client *http.Client) *TestAPI {
+	t := td.NewT(tb)
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		t.Helper()
+		t.Fatal(color.Bad("NewTestAPIClient: baseURL is not a valid URL: %s", err))
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &TestAPI{
+		t:       t,
+		client:  client,
+		baseURL: u,
+	}
+}
+
+// sub returns a new [*TestAPI] instance based on tb, sharing the
+// same handler or client as t and a copy of its session.
+func (t *TestAPI) sub(tb testing.TB) *TestAPI {
+	return &TestAPI{
+		t:       td.NewT(tb),
+		handler: t.handler,
+		client:  t.client,
+		baseURL: t.baseURL,
+		session: t.session.copy(),
+	}
+}
+
 // With creates a new [*TestAPI] instance copied from t, but resetting
 // the [testing.TB] instance the tests are based on to tb. The
 // returned instance is independent from t, sharing only the same
-// handler.
+// handler, or the same client and base URL if t has been created by
+// [NewTestAPIClient]. It starts with a copy of t session, see
+// [TestAPI.WithCookieJar], [TestAPI.WithDefaultHeader] & co.
 //
 // It is typically used when the [TestAPI] instance is "reused" in
 // sub-tests, as in:
@@ -102,11 +183,9 @@ func NewTestAPI(tb testing.TB, handler http.Handler) *TestAPI {
 //
 // See [TestAPI.Run] for another way to handle subtests.
 func (t *TestAPI) With(tb testing.TB) *TestAPI {
-	return &TestAPI{
-		t:                td.NewT(tb),
-		handler:          t.handler,
-		autoDumpResponse: t.autoDumpResponse,
-	}
+	nt := t.sub(tb)
+	nt.autoDumpResponse = t.autoDumpResponse
+	return nt
 }
 
 // T returns the internal instance of [*td.T].
@@ -114,10 +193,12 @@ func (t *TestAPI) T() *td.T {
 	return t.t
 }
 
-// Run runs f as a subtest of t called name.
+// Run runs f as a subtest of t called name. The [*TestAPI] instance
+// passed to f starts with a copy of t session, see
+// [TestAPI.WithCookieJar], [TestAPI.WithDefaultHeader] & co.
 func (t *TestAPI) Run(name string, f func(t *TestAPI)) bool {
 	return t.t.Run(name, func(tdt *td.T) {
-		f(NewTestAPI(tdt, t.handler))
+		f(t.sub(tdt))
 	})
 }
 
@@ -144,20 +225,85 @@ func (t *TestAPI) Name(args ...any) *TestAPI {
 // Request sends a new HTTP request to the tested API. Any Cmp* or
 // [TestAPI.NoBody] methods can now be called.
 //
+// If set, the session default headers, base query parameters and
+// stored cookies are added to req before sending it, see
+// [TestAPI.WithCookieJar], [TestAPI.WithDefaultHeader] & co.
+//
+// A response body encoded using gzip and/or deflate, as the
+// Content-Encoding header indicates, is transparently decoded. See
+// [TestAPI.CmpContentEncoding].
+//
 // Note that [TestAPI.Failed] status is reset just after this call.
 func (t *TestAPI) Request(req *http.Request) *TestAPI {
+	t.t.Helper()
+
+	t.resetResponse()
+
+	if t.client != nil {
+		t.sendRequest(req)
+	} else {
+		t.session.apply(req)
+		t.handler.ServeHTTP(t.response, req)
+	}
+
+	t.decodeResponseBody()
+	t.session.storeCookies(req, t.response.Result())
+
+	return t
+}
+
+// resetResponse prepares t to record the response of a new request.
+func (t *TestAPI) resetResponse() {
 	t.response = httptest.NewRecorder()
 
 	t.statusFailed = false
 	t.headerFailed = false
 	t.cookiesFailed = false
 	t.bodyFailed = false
+	t.graphQLErrorsExpected = false
+	t.jsonRPC = nil
 	t.sentAt = time.Now().Truncate(0)
 	t.responseDumped = false
+}
 
-	t.handler.ServeHTTP(t.response, req)
+// prepareClientRequest prepares req to be sent by an [http.Client]
+// to the server at baseURL, adding session data if any.
+func (t *TestAPI) prepareClientRequest(req *http.Request, baseURL *url.URL) {
+	// Requests built by NewRequest & co. are server ones
+	req.RequestURI = ""
+	if !req.URL.IsAbs() {
+		u, err := url.Parse(strings.TrimSuffix(baseURL.String(), "/") + req.URL.RequestURI())
+		if err != nil {
+			t.t.Helper()
+			t.t.Fatal(color.Bad("Cannot build request URL: %s", err))
+		}
+		req.URL = u
+		req.Host = ""
+	}
+	t.session.apply(req)
+}
 
-	return t
+// sendRequest sends req to the live server using t.client and
+// records the received response in t.response.
+func (t *TestAPI) sendRequest(req *http.Request) {
+	t.t.Helper()
+	t.prepareClientRequest(req, t.baseURL)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		t.t.Helper()
+		t.t.Fatal(color.Bad("%s %s request failed: %s", req.Method, req.URL, err))
+	}
+	defer resp.Body.Close() //nolint: errcheck
+
+	for k, v := range resp.Header {
+		t.response.Header()[k] = v
+	}
+	t.response.WriteHeader(resp.StatusCode)
+	if _, err = io.Copy(t.response, resp.Body); err != nil {
+		t.t.Helper()
+		t.t.Fatal(color.Bad("%s %s response body cannot be read: %s", req.Method, req.URL, err))
+	}
 }
 
 func (t *TestAPI) checkRequestSent() bool {
@@ -794,6 +940,18 @@ func (t *TestAPI) CmpMarshaledBody(unmarshal func([]byte, any) error, expectedBo
 //	  CmpStatus(http.StatusOK).
 //	  CmpBody(td.Contains("OK"))
 //
+// If the Content-Type header of the response specifies an ISO-8859-1
+// or UTF-16 charset, the body is transcoded to UTF-8 before being
+// compared, unless expectedBody is a []byte or an operator whose
+// type behind is []byte:
+//
+//	ta.Get("/latin1").
+//	  CmpStatus(http.StatusOK).
+//	  CmpHeader(td.SuperMapOf(http.Header{
+//	    "Content-Type": {"text/plain; charset=iso-8859-1"},
+//	  }, nil)).
+//	  CmpBody("Café")
+//
 // It fails if no request has been sent yet.
 func (t *TestAPI) CmpBody(expectedBody any) *TestAPI {
 	t.t.Helper()
@@ -807,11 +965,11 @@ func (t *TestAPI) CmpBody(expectedBody any) *TestAPI {
 		func(body []byte, target any) error {
 			switch target := target.(type) {
 			case *string:
-				*target = string(body)
+				*target = string(charsetToUTF8(body, t.response.Header().Get("Content-Type")))
 			case *[]byte:
 				*target = body
 			case *any:
-				*target = body
+				*target = charsetToUTF8(body, t.response.Header().Get("Content-Type"))
 			default:
 				// cmpMarshaledBody always calls us with target as a pointer
 				return fmt.Errorf(
@@ -1056,7 +1214,22 @@ func (t *TestAPI) dumpResponse() {
 	t.t.Helper()
 	if t.response != nil {
 		t.responseDumped = true
-		internal.DumpResponse(t.t, t.response.Result())
+
+		resp := *t.response.Result()
+		body := charsetToUTF8(t.response.Body.Bytes(), resp.Header.Get("Content-Type"))
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		// As body can be decoded and/or transcoded, the original
+		// Content-Length cannot be trusted anymore
+		header := make(http.Header, len(resp.Header))
+		for k, v := range resp.Header {
+			header[k] = v
+		}
+		header.Del("Content-Length")
+		resp.Header = header
+		resp.ContentLength = int64(len(body))
+
+		internal.DumpResponse(t.t, &resp)
 		return
 	}
 
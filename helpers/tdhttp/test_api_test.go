@@ -19,6 +19,7 @@ import (
 
 	"github.com/maxatome/go-testdeep/helpers/tdhttp"
 	"github.com/maxatome/go-testdeep/helpers/tdutil"
+	"github.com/maxatome/go-testdeep/internal/test"
 	"github.com/maxatome/go-testdeep/td"
 )
 
@@ -1059,3 +1060,79 @@ func TestRun(t *testing.T) {
 	})
 	td.CmpFalse(t, ok)
 }
+
+func TestNewTestAPIClient(t *testing.T) {
+	mux := server()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		tt := tdutil.NewT("test")
+		ta := tdhttp.NewTestAPIClient(tt, srv.URL, nil)
+
+		td.CmpFalse(t, ta.Get("/any", tdhttp.Q{"a": 1}).
+			CmpStatus(200).
+			CmpHeader(td.SuperMapOf(http.Header{"X-Testdeep-Method": {"GET"}}, nil)).
+			CmpBody("GET!").
+			Failed())
+
+		td.CmpFalse(t, ta.PostJSON("/any/json", map[string]int{"x": 1}).
+			CmpStatus(200).
+			CmpJSONBody(td.JSON(`{"method":"POST","body":{"x":1}}`)).
+			Failed())
+
+		td.CmpFalse(t, ta.Get("/any/cookies").
+			CmpStatus(200).
+			CmpCookies(td.SuperBagOf(td.Smuggle("Name", "second"))).
+			Failed())
+
+		// Absolute target
+		td.CmpFalse(t, ta.Head(srv.URL+"/any").CmpStatus(200).NoBody().Failed())
+
+		// Run inherits the client
+		td.CmpTrue(t, ta.Run("sub", func(ta *tdhttp.TestAPI) {
+			td.CmpFalse(t, ta.Get("/any").CmpStatus(200).Failed())
+		}))
+		td.CmpFalse(t, ta.With(tdutil.NewT("test2")).Get("/any").CmpStatus(200).Failed())
+
+		td.CmpTrue(t, ta.Get("/any").CmpStatus(404).Failed())
+	})
+
+	t.Run("Base URL with path", func(t *testing.T) {
+		api := http.NewServeMux()
+		api.Handle("/api/", http.StripPrefix("/api", mux))
+		srv := httptest.NewServer(api)
+		defer srv.Close()
+
+		ta := tdhttp.NewTestAPIClient(tdutil.NewT("test"), srv.URL+"/api/", nil)
+		td.CmpFalse(t, ta.Get("/any").CmpStatus(200).CmpBody("GET!").Failed())
+	})
+
+	t.Run("TLS", func(t *testing.T) {
+		srv := httptest.NewTLSServer(mux)
+		defer srv.Close()
+
+		ta := tdhttp.NewTestAPIClient(tdutil.NewT("test"), srv.URL, srv.Client())
+		td.CmpFalse(t, ta.Get("/any").CmpStatus(200).CmpBody("GET!").Failed())
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		tb := test.NewTestingTB("test")
+		ta := tdhttp.NewTestAPIClient(tb, srv.URL, &http.Client{Timeout: 50 * time.Millisecond})
+		td.CmpHasPrefix(t, tb.CatchFatal(func() { ta.Get("/slow") }),
+			"GET "+srv.URL+"/slow request failed: ")
+
+		tb = test.NewTestingTB("test")
+		td.CmpHasPrefix(t,
+			tb.CatchFatal(func() { tdhttp.NewTestAPIClient(tb, ":bad", nil) }),
+			"NewTestAPIClient: baseURL is not a valid URL: ")
+	})
+
+}
@@ -0,0 +1,504 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/maxatome/go-testdeep/helpers/tdhttp/internal"
+	"github.com/maxatome/go-testdeep/internal/color"
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/types"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// wsCloseTimeout is the maximum duration [WebSocket.CmpClose] and
+// [WebSocket.Close] wait for the close frame of the server.
+const wsCloseTimeout = 5 * time.Second
+
+var errWSClosed = errors.New("connection closed by server")
+
+// WebSocket is a WebSocket conversation with the tested API, as
+// returned by [TestAPI.WebSocket].
+type WebSocket struct {
+	t    *td.T
+	name string
+
+	conn     net.Conn
+	br       *bufio.Reader
+	shutdown func()
+
+	status    int // handshake response status
+	closeSent bool
+	closeCode int
+
+	failed bool
+}
+
+// WebSocket opens a WebSocket connection to the tested API at target
+// and returns the corresponding conversation, allowing to send and
+// receive messages:
+//
+//	ws := ta.WebSocket("/chat", "Authorization", "Bearer tok3n")
+//	ws.SendJSON(map[string]string{"msg": "hello"}).
+//	  CmpReceiveJSON(td.JSON(`{"from": "bot", "msg": "hello back"}`), time.Second).
+//	  Send("bye").
+//	  CmpClose(1000)
+//
+// As for [TestAPI.RequestSSE], the handler of a [TestAPI] created by
+// [NewTestAPI] is served by an [httptest.Server] started for the
+// conversation. The client used is a small RFC 6455 implementation,
+// without extensions nor subprotocols negotiation.
+//
+// The handshake response is recorded as the last response of ta, so
+// [TestAPI.CmpStatus], [TestAPI.CmpHeader] & co. can be used to
+// check it. If the handshake fails, for example with a 401 status,
+// the test does not fail at once, but all methods of the returned
+// conversation then fail:
+//
+//	ta.WebSocket("/chat")
+//	ta.CmpStatus(http.StatusUnauthorized)
+//
+// Starting go1.14, the connection is automatically closed at the end
+// of the test if [WebSocket.CmpClose] or [WebSocket.Close] have not
+// been called before. For previous versions, one of them has to be
+// called explicitly, otherwise the connection and the server started
+// for it leak.
+//
+// See [NewRequest] for all possible formats accepted in headersQueryParams.
+func (t *TestAPI) WebSocket(target string, headersQueryParams ...any) *WebSocket {
+	t.t.Helper()
+
+	req, err := get(target, headersQueryParams...)
+	if err != nil {
+		t.t.Fatal(err)
+	}
+
+	t.resetResponse()
+
+	ws := &WebSocket{t: t.t, name: t.name}
+
+	client, baseURL := t.client, t.baseURL
+	if client == nil {
+		srv := httptest.NewServer(t.handler)
+		ws.shutdown = func() {
+			srv.CloseClientConnections()
+			srv.Close()
+		}
+		client = srv.Client()
+		baseURL, _ = url.Parse(srv.URL) // never fails
+	}
+	cleanupTB(t.t, ws.close)
+
+	t.prepareClientRequest(req, baseURL)
+
+	ws.conn, err = dialWebSocket(client, req.URL)
+	if err != nil {
+		ws.close()
+		t.t.Fatal(color.Bad("WebSocket %s cannot connect: %s", req.URL, err))
+	}
+
+	key := internal.WebSocketKey()
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	ws.br = bufio.NewReader(ws.conn)
+	resp, err := sendWebSocketHandshake(ws.conn, ws.br, req)
+	if err != nil {
+		ws.close()
+		t.t.Fatal(color.Bad("WebSocket %s handshake failed: %s", req.URL, err))
+	}
+
+	for k, v := range resp.Header {
+		t.response.Header()[k] = v
+	}
+	t.response.WriteHeader(resp.StatusCode)
+	t.session.storeCookies(req, t.response.Result())
+
+	ws.status = resp.StatusCode
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		io.Copy(t.response, resp.Body) //nolint: errcheck
+		t.decodeResponseBody()
+		ws.close()
+		return ws
+	}
+
+	ws.t.RootName("Response.Header").
+		Code(resp.Header.Get("Sec-WebSocket-Accept"),
+			func(accept string) error {
+				if accept == internal.WebSocketAccept(key) {
+					return nil
+				}
+				ws.close()
+				ws.failed = true
+				return &ctxerr.Error{
+					Message: "%% Sec-WebSocket-Accept is not valid",
+					Summary: ctxerr.NewSummary(fmt.Sprintf("got %q, but expected %q",
+						accept, internal.WebSocketAccept(key))),
+				}
+			},
+			t.name+"WebSocket handshake")
+
+	return ws
+}
+
+// dialWebSocket opens a TCP connection, or a TLS one if u scheme is
+// https, to the host of u. If client uses an [*http.Transport], its
+// TLS configuration is used.
+func dialWebSocket(client *http.Client, u *url.URL) (net.Conn, error) {
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "https" {
+			host += ":443"
+		} else {
+			host += ":80"
+		}
+	}
+
+	if u.Scheme != "https" {
+		return net.DialTimeout("tcp", host, 10*time.Second)
+	}
+
+	var config *tls.Config
+	if tr, ok := client.Transport.(*http.Transport); ok && tr.TLSClientConfig != nil {
+		config = tr.TLSClientConfig.Clone()
+	} else {
+		config = &tls.Config{} //nolint: gosec
+	}
+	if config.ServerName == "" {
+		config.ServerName = u.Hostname()
+	}
+	return tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", host, config)
+}
+
+func sendWebSocketHandshake(conn net.Conn, br *bufio.Reader, req *http.Request) (*http.Response, error) {
+	conn.SetDeadline(time.Now().Add(10 * time.Second)) //nolint: errcheck
+	defer conn.SetDeadline(time.Time{})                //nolint: errcheck
+
+	if err := req.Write(conn); err != nil {
+		return nil, err
+	}
+	return http.ReadResponse(br, req)
+}
+
+// close closes the connection and stops the server, if any.
+func (ws *WebSocket) close() {
+	if ws.conn != nil {
+		ws.conn.Close() //nolint: errcheck
+		ws.conn = nil
+	}
+	if ws.shutdown != nil {
+		ws.shutdown()
+		ws.shutdown = nil
+	}
+}
+
+// fail reports a failure of the conversation.
+func (ws *WebSocket) fail(message, summary, testName string) {
+	ws.t.Helper()
+	ws.failed = true
+	ws.t.RootName("WebSocket").
+		Code(false, func(bool) error {
+			return &ctxerr.Error{
+				Message: message,
+				Summary: ctxerr.NewSummary(summary),
+			}
+		},
+			ws.name+testName)
+}
+
+func (ws *WebSocket) checkOpen(testName string) bool {
+	ws.t.Helper()
+	if ws.status != http.StatusSwitchingProtocols {
+		ws.fail("%% is not established",
+			fmt.Sprintf("handshake response status is %d", ws.status), testName)
+		return false
+	}
+	if ws.conn == nil {
+		ws.fail("%% is closed", "the connection has been closed", testName)
+		return false
+	}
+	return true
+}
+
+func (ws *WebSocket) write(opcode byte, payload []byte) error {
+	return internal.WriteWebSocketFrame(ws.conn,
+		internal.WebSocketFrame{Fin: true, Opcode: opcode, Payload: payload},
+		true)
+}
+
+// readMessage reads the next data message, answering to pings on the
+// fly. If a close frame is received, it is answered and errWSClosed
+// is returned.
+func (ws *WebSocket) readMessage(timeout time.Duration) (opcode byte, payload []byte, err error) {
+	if timeout > 0 {
+		ws.conn.SetReadDeadline(time.Now().Add(timeout)) //nolint: errcheck
+	} else {
+		ws.conn.SetReadDeadline(time.Time{}) //nolint: errcheck
+	}
+
+	for {
+		f, err := internal.ReadWebSocketFrame(ws.br)
+		if err != nil {
+			if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+				ws.close()
+			}
+			return 0, nil, err
+		}
+
+		switch f.Opcode {
+		case internal.WSPing:
+			ws.write(internal.WSPong, f.Payload) //nolint: errcheck
+
+		case internal.WSPong:
+
+		case internal.WSClose:
+			ws.closeCode, _ = internal.ParseWebSocketClosePayload(f.Payload)
+			if !ws.closeSent {
+				ws.closeSent = true
+				// Echo the status code only, as RFC 6455 recommends
+				echo := f.Payload
+				if len(echo) > 2 {
+					echo = echo[:2]
+				}
+				ws.write(internal.WSClose, echo) //nolint: errcheck
+			}
+			ws.close()
+			return 0, nil, errWSClosed
+
+		case internal.WSContinuation:
+			if opcode == 0 {
+				ws.close()
+				return 0, nil, errors.New("unexpected continuation frame")
+			}
+			payload = append(payload, f.Payload...)
+			if f.Fin {
+				return opcode, payload, nil
+			}
+
+		default: // text or binary
+			if f.Fin {
+				return f.Opcode, f.Payload, nil
+			}
+			opcode, payload = f.Opcode, f.Payload
+		}
+	}
+}
+
+// Failed returns true if any method of ws failed since its creation.
+func (ws *WebSocket) Failed() bool {
+	return ws.failed
+}
+
+// Send sends message, that can be a string sent as a text message,
+// or a []byte sent as a binary message.
+//
+// It fails if the conversation is not established or closed.
+func (ws *WebSocket) Send(message any) *WebSocket {
+	ws.t.Helper()
+
+	var (
+		opcode  byte
+		payload []byte
+	)
+	switch m := message.(type) {
+	case string:
+		opcode, payload = internal.WSText, []byte(m)
+	case []byte:
+		opcode, payload = internal.WSBinary, m
+	default:
+		ws.t.Fatal(color.BadUsage("Send(STRING|[]BYTE)", message, 1, true))
+	}
+
+	if ws.checkOpen("WebSocket message sent") {
+		if err := ws.write(opcode, payload); err != nil {
+			ws.fail("%% message cannot be sent", err.Error(), "WebSocket message sent")
+		}
+	}
+	return ws
+}
+
+// SendJSON sends the JSON representation of message as a text
+// message.
+//
+// It fails if the conversation is not established or closed.
+func (ws *WebSocket) SendJSON(message any) *WebSocket {
+	ws.t.Helper()
+
+	b, err := json.Marshal(message)
+	if err != nil {
+		ws.t.Fatal(color.Bad("SendJSON: JSON encoding failed: %s", err))
+	}
+	return ws.Send(string(b))
+}
+
+func (ws *WebSocket) receive(timeout time.Duration, testName string) ([]byte, bool) {
+	ws.t.Helper()
+
+	if !ws.checkOpen(testName) {
+		return nil, false
+	}
+
+	_, payload, err := ws.readMessage(timeout)
+	if err != nil {
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			ws.fail("%% message not received", "no message received after "+timeout.String(), testName)
+		} else {
+			ws.fail("%% message not received", err.Error(), testName)
+		}
+		return nil, false
+	}
+	return payload, true
+}
+
+// CmpReceive waits for the next message during timeout at most (0
+// meaning no timeout) and checks it matches expected. expected can
+// be a string, a []byte or a [td.TestDeep] operator. The received
+// message, text or binary, is compared as a []byte if expected is a
+// []byte or an operator whose type behind is []byte, as a string
+// otherwise.
+//
+//	ws.Send("ping").
+//	  CmpReceive("pong", time.Second)
+//
+// Ping frames received in the meantime are automatically answered.
+//
+// It fails if the conversation is not established or closed, or if
+// no message is received before timeout.
+func (ws *WebSocket) CmpReceive(expected any, timeout time.Duration) *WebSocket {
+	ws.t.Helper()
+
+	payload, ok := ws.receive(timeout, "WebSocket message received")
+	if !ok {
+		return ws
+	}
+
+	typ := reflect.TypeOf(expected)
+	if op, ok := expected.(td.TestDeep); ok {
+		typ = op.TypeBehind()
+	}
+
+	var got any = string(payload)
+	if typ != nil && typ.Kind() == reflect.Slice && typ.Elem() == types.Uint8 {
+		got = payload
+	}
+	if !ws.t.RootName("WebSocket.Message").
+		Cmp(got, expected, ws.name+"WebSocket message contents is OK") {
+		ws.failed = true
+	}
+	return ws
+}
+
+// CmpReceiveJSON waits for the next message during timeout at most
+// (0 meaning no timeout), JSON unmarshals it and checks it matches
+// expected. As for [TestAPI.CmpJSONBody], expected can be any type
+// one can [json.Unmarshal] into, or a [td.TestDeep] operator.
+//
+//	ws.SendJSON(map[string]string{"cmd": "who"}).
+//	  CmpReceiveJSON(td.JSON(`{"users": ["bob", "alice"]}`), time.Second)
+//
+// It fails if the conversation is not established or closed, if no
+// message is received before timeout or if it cannot be unmarshaled.
+func (ws *WebSocket) CmpReceiveJSON(expected any, timeout time.Duration) *WebSocket {
+	ws.t.Helper()
+
+	payload, ok := ws.receive(timeout, "WebSocket JSON message received")
+	if !ok {
+		return ws
+	}
+
+	typ := reflect.TypeOf(expected)
+	if op, ok := expected.(td.TestDeep); ok {
+		typ = op.TypeBehind()
+	}
+	if typ == nil {
+		typ = types.Interface
+	}
+
+	ptr := reflect.New(typ)
+	if !ws.t.RootName("unmarshal(WebSocket.Message)").
+		CmpNoError(json.Unmarshal(payload, ptr.Interface()), ws.name+"WebSocket message unmarshaling") ||
+		!ws.t.RootName("WebSocket.Message").
+			Cmp(ptr.Elem().Interface(), expected, ws.name+"WebSocket message contents is OK") {
+		ws.failed = true
+	}
+	return ws
+}
+
+// waitClose waits for the close frame of the server, discarding data
+// messages received in the meantime.
+func (ws *WebSocket) waitClose() error {
+	deadline := time.Now().Add(wsCloseTimeout)
+	for {
+		_, _, err := ws.readMessage(time.Until(deadline))
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// CmpClose waits for the server to close the conversation and checks
+// the close code matches expectedCode, that can be an int or a
+// [td.TestDeep] operator. Data messages received in the meantime are
+// discarded. 1005 code means the close frame contains no code.
+//
+//	ws.Send("quit").
+//	  CmpClose(1000)
+//
+// It waits 5 seconds at most. The connection is then closed.
+//
+// It fails if the conversation is not established, if the connection
+// is broken or if the server does not close it in time.
+func (ws *WebSocket) CmpClose(expectedCode any) *WebSocket {
+	ws.t.Helper()
+
+	const testName = "WebSocket close code"
+	if ws.closeCode == 0 {
+		if !ws.checkOpen(testName) {
+			return ws
+		}
+		if err := ws.waitClose(); err != errWSClosed {
+			ws.close()
+			ws.fail("%% not closed by server", err.Error(), testName)
+			return ws
+		}
+	}
+
+	if !ws.t.RootName("WebSocket.CloseCode").
+		Cmp(ws.closeCode, expectedCode, ws.name+testName) {
+		ws.failed = true
+	}
+	return ws
+}
+
+// Close closes the conversation, sending a close frame with 1000
+// code (normal closure) if none has been sent yet, then waiting for
+// the close frame of the server during 5 seconds at most.
+//
+// It never fails.
+func (ws *WebSocket) Close() {
+	if ws.conn != nil && !ws.closeSent {
+		ws.closeSent = true
+		if ws.write(internal.WSClose, internal.WebSocketClosePayload(1000, "")) == nil {
+			ws.waitClose() //nolint: errcheck
+		}
+	}
+	ws.close()
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package tdhttp_test
+
+import (
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/helpers/tdhttp"
+	"github.com/maxatome/go-testdeep/helpers/tdhttp/internal"
+	"github.com/maxatome/go-testdeep/internal/test"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+// wsServer returns a WebSocket echo server, only accepting "Bearer
+// tok3n" authorization. "Bearer gz" one is rejected with a gzip
+// encoded body. Some messages trigger special behaviors:
+//   - "close" makes the server close the connection with 4000 code;
+//   - "ping" makes the server send a ping before answering "pong";
+//   - "fragmented" is answered in 2 fragments;
+//   - "silence" is not answered.
+func wsServer() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Upgrade") != "websocket" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch req.Header.Get("Authorization") {
+		case "Bearer tok3n":
+		case "Bearer gz":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.WriteHeader(http.StatusUnauthorized)
+			gz := gzip.NewWriter(w)
+			gz.Write([]byte("forbidden\n")) //nolint: errcheck
+			gz.Close()                      //nolint: errcheck
+			return
+		default:
+			http.Error(w, "forbidden", http.StatusUnauthorized)
+			return
+		}
+
+		conn, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close() //nolint: errcheck
+
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" + //nolint: errcheck
+			"Upgrade: websocket\r\n" +
+			"Connection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " +
+			internal.WebSocketAccept(req.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		brw.Flush() //nolint: errcheck
+
+		send := func(fin bool, opcode byte, payload string) {
+			internal.WriteWebSocketFrame(conn, //nolint: errcheck
+				internal.WebSocketFrame{Fin: fin, Opcode: opcode, Payload: []byte(payload)},
+				false)
+		}
+
+		for {
+			f, err := internal.ReadWebSocketFrame(brw)
+			if err != nil {
+				return
+			}
+			switch f.Opcode {
+			case internal.WSClose:
+				send(true, internal.WSClose, string(f.Payload))
+				return
+			case internal.WSPong:
+				send(true, internal.WSText, "pong")
+				continue
+			}
+
+			switch string(f.Payload) {
+			case "close":
+				send(true, internal.WSText, "closing")
+				send(true, internal.WSClose, string(internal.WebSocketClosePayload(4000, "bye")))
+			case "ping":
+				send(true, internal.WSPing, "xxx")
+			case "fragmented":
+				send(false, internal.WSText, "frag")
+				send(true, internal.WSContinuation, "mented")
+			case "silence":
+			default:
+				send(true, f.Opcode, string(f.Payload))
+			}
+		}
+	})
+
+	return mux
+}
+
+func TestWebSocket(t *testing.T) {
+	mux := wsServer()
+
+	check := func(t *testing.T, ta *tdhttp.TestAPI) {
+		t.Helper()
+
+		ws := ta.WebSocket("/ws", "Authorization", "Bearer tok3n")
+		ta.CmpStatus(http.StatusSwitchingProtocols).
+			CmpHeader(td.SuperMapOf(http.Header{"Upgrade": {"websocket"}}, nil))
+
+		ws.Send("hello").
+			CmpReceive("hello", time.Second).
+			Send([]byte("bin")).
+			CmpReceive([]byte("bin"), time.Second).
+			Send("fragmented").
+			CmpReceive(td.HasPrefix("frag"), time.Second).
+			Send("ping").
+			CmpReceive("pong", time.Second).
+			SendJSON(map[string]int{"id": 42}).
+			CmpReceiveJSON(td.JSON(`{"id": $1}`, td.Between(40, 45)), time.Second).
+			SendJSON([]int{1, 2}).
+			CmpReceiveJSON([]int{1, 2}, time.Second).
+			Send("close").
+			CmpClose(4000)
+		td.CmpFalse(t, ws.Failed())
+
+		// Client initiated close
+		ws = ta.WebSocket("/ws", "Authorization", "Bearer tok3n")
+		ws.Send("hello").CmpReceive("hello", time.Second).Close()
+		td.CmpFalse(t, ws.Failed())
+
+		// Rejected upgrade
+		ta.WebSocket("/ws")
+		ta.CmpStatus(http.StatusUnauthorized).
+			CmpBody("forbidden\n")
+		td.CmpFalse(t, ta.Failed())
+
+		// Rejected upgrade, compressed body
+		ta.WebSocket("/ws", "Authorization", "Bearer gz")
+		ta.CmpStatus(http.StatusUnauthorized).
+			CmpContentEncoding("gzip").
+			CmpBody("forbidden\n")
+		td.CmpFalse(t, ta.Failed())
+	}
+
+	t.Run("Handler", func(t *testing.T) {
+		check(t, tdhttp.NewTestAPI(t, mux))
+	})
+
+	t.Run("Client", func(t *testing.T) {
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		check(t, tdhttp.NewTestAPIClient(t, srv.URL, nil))
+	})
+
+	t.Run("TLS", func(t *testing.T) {
+		srv := httptest.NewTLSServer(mux)
+		defer srv.Close()
+
+		check(t, tdhttp.NewTestAPIClient(t, srv.URL, srv.Client()))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tb := test.NewTestingTB("test")
+		ta := tdhttp.NewTestAPI(tb, mux)
+
+		ws := ta.WebSocket("/ws")
+		td.CmpTrue(t, ws.Send("hello").Failed(), "not established")
+		td.Cmp(t, tb.LastMessage(), td.All(
+			td.Contains("WebSocket is not established"),
+			td.Contains("handshake response status is 401"),
+		))
+
+		ws = ta.WebSocket("/ws", "Authorization", "Bearer tok3n")
+		td.CmpTrue(t, ws.Send("silence").CmpReceive("foo", 50*time.Millisecond).Failed(),
+			"timeout")
+		td.Cmp(t, tb.LastMessage(), td.Contains("no message received after 50ms"))
+
+		ws = ta.WebSocket("/ws", "Authorization", "Bearer tok3n")
+		td.CmpTrue(t, ws.Send("hello").CmpReceive("bye", time.Second).Failed(),
+			"bad message")
+		td.Cmp(t, tb.LastMessage(), td.Contains("WebSocket.Message: values differ"))
+
+		ws = ta.WebSocket("/ws", "Authorization", "Bearer tok3n")
+		td.CmpTrue(t, ws.Send("hello").CmpReceiveJSON(td.Ignore(), time.Second).Failed(),
+			"bad JSON")
+		td.Cmp(t, tb.LastMessage(), td.Contains("unmarshal(WebSocket.Message)"))
+
+		ws = ta.WebSocket("/ws", "Authorization", "Bearer tok3n")
+		td.CmpTrue(t, ws.Send("close").CmpClose(1000).Failed(), "bad close code")
+		td.Cmp(t, tb.LastMessage(), td.Contains("WebSocket.CloseCode: values differ"))
+		td.CmpTrue(t, ws.Send("hello").Failed(), "closed")
+		td.Cmp(t, tb.LastMessage(), td.Contains("WebSocket is closed"))
+
+		td.CmpContains(t, tb.CatchFatal(func() { ws.Send(12) }),
+			"usage: Send(STRING|[]BYTE), but received int as 1st parameter")
+	})
+}
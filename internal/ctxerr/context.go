@@ -7,7 +7,9 @@
 package ctxerr
 
 import (
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/maxatome/go-testdeep/internal/anchors"
 	"github.com/maxatome/go-testdeep/internal/hooks"
@@ -44,17 +46,125 @@ type Context struct {
 	BeLax bool
 	// See ContextConfig.IgnoreUnexported for details.
 	IgnoreUnexported bool
+	// See ContextConfig.EquateEmpty for details.
+	EquateEmpty bool
+	// See ContextConfig.UseStructTags for details.
+	UseStructTags bool
+	// See ContextConfig.IgnoreSliceOrder for details.
+	IgnoreSliceOrder bool
+	// IgnoreSliceOrderPaths contains the patterns of paths of slices
+	// whose order has to be ignored. See
+	// ContextConfig.IgnoreSliceOrderPaths for details.
+	IgnoreSliceOrderPaths []PathPattern
+	// SourceLines is the number of source lines displayed around the
+	// location of the operator originator of an error. 0 or less
+	// disables this feature. See ContextConfig.SourceLines for details.
+	SourceLines int
+	// PathStyle is the style used to render Path in errors. See
+	// ContextConfig.PathStyle for details.
+	PathStyle PathStyle
+	// If true, errors sharing the same message, operator location and
+	// path pattern (array indexes replaced by "*") are grouped. See
+	// ContextConfig.GroupErrors for details.
+	GroupErrors bool
+	// IgnorePaths contains the patterns of paths to skip during
+	// traversal. See ContextConfig.IgnorePaths for details.
+	IgnorePaths []PathPattern
+	// FloatTolerance is the tolerance used to compare floats and
+	// complex numbers. See ContextConfig.FloatTolerance for details.
+	FloatTolerance FloatTolerance
+	// TimeTolerance is the tolerance used to compare time.Time
+	// values. See ContextConfig.TimeTolerance for details.
+	TimeTolerance time.Duration
+	// ScopedHooks contains the Cmp hooks only applying to paths
+	// matching a pattern. See (*td.T).WithCmpHooksAt for details.
+	ScopedHooks []ScopedHooks
+	// Aliases, if non-nil, records the pairing of got and expected
+	// pointers to check their sharing topology. See
+	// ContextConfig.CheckAliasing for details.
+	Aliases *visited.Aliases
+	// MaxConversionHops is the maximum number of converters chained
+	// to convert got to expected type. See
+	// ContextConfig.MaxConversionHops for details.
+	MaxConversionHops int
+	// items counts, when GroupErrors is true, the number of visited
+	// items behind each path pattern
+	items map[string]int
+	// groups records, when GroupErrors is true, the distinct groups of
+	// the errors collected so far
+	groups map[groupKey]struct{}
 }
 
-// InitErrors initializes [Context] *Errors slice, if MaxErrors < 0 or
-// MaxErrors > 1.
+// ScopedHooks associates hooks to a path pattern.
+type ScopedHooks struct {
+	Pattern PathPattern
+	Hooks   *hooks.Info
+}
+
+// FloatTolerance defines the tolerances used to compare floats and
+// complex numbers. Two numbers are equal if at least one of the
+// non-zero tolerances is satisfied.
+type FloatTolerance struct {
+	Absolute float64 // maximum absolute difference
+	Relative float64 // maximum difference relative to the largest magnitude
+	ULPs     uint64  // maximum number of units in the last place
+}
+
+// InitErrors initializes [Context] *Errors slice, if MaxErrors < 0,
+// MaxErrors > 1 or GroupErrors is true.
 func (c *Context) InitErrors() {
+	if c.GroupErrors {
+		var errors []*Error
+		c.Errors = &errors
+		if c.items == nil {
+			c.items = map[string]int{}
+		}
+		c.groups = map[groupKey]struct{}{}
+		return
+	}
+
 	if c.MaxErrors != 0 && c.MaxErrors != 1 {
 		var errors []*Error
 		c.Errors = &errors
 	}
 }
 
+// IsIgnoredPath returns true if c Path matches one of c IgnorePaths
+// patterns.
+func (c Context) IsIgnoredPath() bool {
+	return matchAny(c.IgnorePaths, c.Path)
+}
+
+// IsUnorderedPath returns true if c Path matches one of c
+// IgnoreSliceOrderPaths patterns.
+func (c Context) IsUnorderedPath() bool {
+	return matchAny(c.IgnoreSliceOrderPaths, c.Path)
+}
+
+// CmpHooks calls the first Cmp hook matching got and expected
+// types. Hooks whose pattern matches c Path are tried first, the last
+// recorded ones first, before the global ones. It returns the same as
+// [hooks.Info.Cmp].
+func (c Context) CmpHooks(got, expected reflect.Value) (bool, error) {
+	for i := len(c.ScopedHooks) - 1; i >= 0; i-- {
+		if c.ScopedHooks[i].Pattern.Match(c.Path) {
+			if handled, err := c.ScopedHooks[i].Hooks.Cmp(got, expected); handled {
+				return true, err
+			}
+		}
+	}
+	return c.Hooks.Cmp(got, expected)
+}
+
+func matchAny(patterns []PathPattern, path Path) bool {
+	for _, pattern := range patterns {
+		if pattern.Match(path) {
+			return true
+		}
+	}
+	return false
+}
+
 // ResetErrors returns a new [Context] without any Error set.
 func (c Context) ResetErrors() (new Context) {
 	new = c
@@ -93,6 +203,18 @@ func (c Context) CollectError(err *Error) *Error {
 
 	// Else, accumulate...
 	*c.Errors = append(*c.Errors, err)
+
+	// When grouping, MaxErrors applies to groups, see MergeErrors
+	if c.GroupErrors {
+		key, _, _ := errorGroupKey(err)
+		c.groups[key] = struct{}{}
+		if c.MaxErrors == 0 || c.MaxErrors == 1 ||
+			(c.MaxErrors > 1 && len(c.groups) > c.MaxErrors) {
+			return c.MergeErrors()
+		}
+		return nil
+	}
+
 	if c.MaxErrors >= 0 && len(*c.Errors) >= c.MaxErrors {
 		*c.Errors = append(*c.Errors, ErrTooManyErrors)
 		return c.MergeErrors()
@@ -102,11 +224,25 @@ func (c Context) CollectError(err *Error) *Error {
 
 // MergeErrors merges all collected errors in the first one and
 // returns it. It returns nil if no errors have been collected.
+//
+// If GroupErrors is true, errors are grouped before being merged,
+// then MaxErrors is applied to the resulting groups.
 func (c Context) MergeErrors() *Error {
 	if c.Errors == nil || len(*c.Errors) == 0 {
 		return nil
 	}
 
+	if c.GroupErrors {
+		errors := groupErrors(*c.Errors, c.items)
+		switch {
+		case c.MaxErrors == 0 || c.MaxErrors == 1:
+			errors = errors[:1]
+		case c.MaxErrors > 1 && len(errors) > c.MaxErrors:
+			errors = append(errors[:c.MaxErrors:c.MaxErrors], ErrTooManyErrors)
+		}
+		*c.Errors = errors
+	}
+
 	if len(*c.Errors) > 1 {
 		for idx, last := 0, len(*c.Errors)-2; idx <= last; idx++ {
 			(*c.Errors)[idx].Next = (*c.Errors)[idx+1]
@@ -143,12 +279,26 @@ func (c Context) AddField(field string) (new Context) {
 	return
 }
 
+// AddStructField creates a new [Context] from current one plus "." +
+// field name. Contrary to [Context.AddField], the JSON name of field
+// is recorded too.
+func (c Context) AddStructField(field reflect.StructField) (new Context) {
+	new = c
+	new.Path = new.Path.AddStructField(field)
+	new.Depth++
+	return
+}
+
 // AddArrayIndex creates a new [Context] from current one plus an array
 // dereference for index-th item.
 func (c Context) AddArrayIndex(index int) (new Context) {
 	new = c
 	new.Path = new.Path.AddArrayIndex(index)
 	new.Depth++
+	if c.items != nil && !c.BooleanError {
+		pattern, _ := new.Path.Generalize()
+		c.items[itemsKey(pattern)]++
+	}
 	return
 }
 
@@ -185,3 +335,11 @@ func (c Context) ResetPath(newRoot string) (new Context) {
 	new.Depth++
 	return
 }
+
+// ResetExprPath creates a new [Context] from current one but
+// reinitializing Path using [NewExprPath].
+func (c Context) ResetExprPath(expr string) (new Context) {
+	new = c.ResetPath("")
+	new.Path = NewExprPath(expr)
+	return
+}
@@ -262,4 +262,8 @@ func TestContextPath(t *testing.T) {
 	ctx = ctx.ResetPath("NEW")
 	test.EqualStr(t, ctx.Path.String(), "NEW")
 	test.EqualInt(t, ctx.Depth, 2)
+
+	ctx = ctx.ResetExprPath("(NEW)")
+	test.EqualStr(t, ctx.Path.StringStyle(ctxerr.PathStyleJSONPointer), "(NEW)")
+	test.EqualInt(t, ctx.Depth, 3)
 }
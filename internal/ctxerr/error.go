@@ -30,6 +30,9 @@ type Error struct {
 	// If not nil, Summary is used to display summary instead of using
 	// Got + Expected fields
 	Summary ErrorSummary
+	// If not empty, Hint is a suggestion displayed after Got +
+	// Expected (or Summary) to help fixing the error
+	Hint string
 	// If initialized, location of TestDeep operator originator of the error
 	Location location.Location
 	// If defined, the current Error comes from this Error
@@ -131,13 +134,15 @@ func (e *Error) Append(buf *bytes.Buffer, prefix string) {
 		return
 	}
 
+	path := e.Context.Path.StringStyle(e.Context.PathStyle)
+
 	buf.WriteString(color.TitleOn)
 	if pos := strings.Index(e.Message, "%%"); pos >= 0 {
 		buf.WriteString(e.Message[:pos])
-		buf.WriteString(e.Context.Path.String())
+		buf.WriteString(path)
 		buf.WriteString(e.Message[pos+2:])
 	} else {
-		buf.WriteString(e.Context.Path.String())
+		buf.WriteString(path)
 		buf.WriteString(": ")
 		buf.WriteString(e.Message)
 	}
@@ -161,6 +166,12 @@ func (e *Error) Append(buf *bytes.Buffer, prefix string) {
 		buf.WriteString(color.OKOff)
 	}
 
+	if e.Hint != "" {
+		writeEolPrefix()
+		buf.WriteString("\t    hint: ")
+		util.IndentStringIn(buf, e.Hint, prefix+"\t          ", "", "")
+	}
+
 	// This error comes from another one
 	if e.Origin != nil {
 		writeEolPrefix()
@@ -176,6 +187,8 @@ func (e *Error) Append(buf *bytes.Buffer, prefix string) {
 		buf.WriteString("[under operator ")
 		buf.WriteString(e.Location.String())
 		buf.WriteByte(']')
+
+		appendSource(buf, prefix+"\t", e.Location, e.Context.SourceLines)
 	}
 
 	if e.Next != nil {
@@ -204,6 +204,32 @@ DATA[13].Field: error message
 	888
 [under operator Operator at file.go:24]`)
 
+	//
+	// With hint
+	err = ctxerr.Error{
+		Context: ctxerr.Context{
+			Path: ctxerr.NewPath("DATA"),
+		},
+		Message:  "type mismatch",
+		Got:      types.RawString("int"),
+		Expected: types.RawString("int64"),
+		Hint:     "use Lax\non 2 lines",
+	}
+	test.EqualStr(t, err.Error(),
+		`DATA: type mismatch
+	     got: int
+	expected: int64
+	    hint: use Lax
+	          on 2 lines`)
+
+	err.Got, err.Expected = nil, nil
+	err.Summary = ctxerr.NewSummary("666")
+	test.EqualStr(t, err.Error(),
+		`DATA: type mismatch
+	666
+	    hint: use Lax
+	          on 2 lines`)
+
 	//
 	// ErrTooManyErrors
 	test.EqualStr(t, ctxerr.ErrTooManyErrors.Error(),
@@ -0,0 +1,237 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package ctxerr
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/maxatome/go-testdeep/internal/color"
+	"github.com/maxatome/go-testdeep/internal/location"
+)
+
+const (
+	// groupMaxIndexes is the maximum number of indexes displayed for a
+	// group of errors.
+	groupMaxIndexes = 5
+	// groupMaxValues is the maximum number of distinct got (or
+	// expected) values displayed for a group of errors.
+	groupMaxValues = 2
+)
+
+// Generalize returns a copy of p where all array indexes are replaced
+// by "*", plus the replaced indexes, in order.
+func (p Path) Generalize() (Path, []string) {
+	var indexes []string
+	new := p.Copy()
+	for i, level := range new {
+		if level.Kind == levelArray {
+			indexes = append(indexes, level.Content)
+			new[i].Content = "*"
+		}
+	}
+	return new, indexes
+}
+
+// itemsKey returns the key used to count the items behind the
+// generalized path p, pointers excluded as they can change while the
+// path grows.
+func itemsKey(p Path) string {
+	var buf bytes.Buffer
+	for _, level := range p {
+		buf.WriteByte(byte('0' + level.Kind))
+		buf.WriteString(level.Content)
+		buf.WriteByte(0)
+	}
+	return buf.String()
+}
+
+type groupKey struct {
+	message  string
+	location location.Location
+	pattern  string
+}
+
+// errorGroupKey returns the key of the group err belongs to, plus
+// the generalized path of err and its replaced array indexes.
+func errorGroupKey(err *Error) (groupKey, Path, []string) {
+	pattern, indexes := err.Context.Path.Generalize()
+
+	key := groupKey{
+		message:  err.Message,
+		location: err.Location,
+		pattern:  pattern.String(),
+	}
+	if len(indexes) == 0 || err == ErrTooManyErrors {
+		// Not groupable: use a key unique to this error
+		key.pattern = fmt.Sprintf("%p", err)
+	}
+	return key, pattern, indexes
+}
+
+type countedValue struct {
+	value string
+	count int
+}
+
+// countedValues records distinct values and their number of occurrences.
+type countedValues []countedValue
+
+func (cv *countedValues) add(value string) {
+	for i := range *cv {
+		if (*cv)[i].value == value {
+			(*cv)[i].count++
+			return
+		}
+	}
+	*cv = append(*cv, countedValue{value: value, count: 1})
+}
+
+// String returns the distinct values, most frequent first.
+func (cv countedValues) String() string {
+	cv = append(countedValues(nil), cv...)
+	sort.SliceStable(cv, func(i, j int) bool { return cv[i].count > cv[j].count })
+
+	var buf bytes.Buffer
+	for i, v := range cv {
+		if i == groupMaxValues {
+			fmt.Fprintf(&buf, "\n… and %d other distinct values", len(cv)-i)
+			break
+		}
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(v.value)
+		if len(cv) > 1 || v.count > 1 {
+			fmt.Fprintf(&buf, " (×%d)", v.count)
+		}
+	}
+	return buf.String()
+}
+
+// errorsGroup is an [ErrorSummary] describing a group of errors
+// sharing the same message, operator location and path pattern.
+type errorsGroup struct {
+	first    *Error
+	num      int
+	total    int
+	indexes  []string
+	got      countedValues
+	expected countedValues
+}
+
+var _ ErrorSummary = (*errorsGroup)(nil)
+
+func (g *errorsGroup) add(err *Error, indexes []string) {
+	g.num++
+	if len(g.indexes) <= groupMaxIndexes {
+		g.indexes = append(g.indexes, "["+strings.Join(indexes, "][")+"]")
+	}
+	if err.Summary == nil {
+		g.got.add(err.GotString())
+		g.expected.add(err.ExpectedString())
+	}
+}
+
+// AppendSummary implements [ErrorSummary] interface.
+func (g *errorsGroup) AppendSummary(buf *bytes.Buffer, prefix string) {
+	items := ErrorSummaryItems{
+		{
+			Label: "items",
+			Value: strconv.Itoa(g.num),
+		},
+	}
+	if g.total >= g.num {
+		items[0].Value = fmt.Sprintf("%d of %d", g.num, g.total)
+	}
+
+	indexes := g.indexes
+	if len(indexes) > groupMaxIndexes {
+		indexes = append(indexes[:groupMaxIndexes:groupMaxIndexes], "…")
+	}
+	items = append(items, ErrorSummaryItem{
+		Label: "indexes",
+		Value: strings.Join(indexes, ", "),
+	})
+
+	if g.first.Summary == nil {
+		items = append(items,
+			ErrorSummaryItem{Label: "got", Value: g.got.String()},
+			ErrorSummaryItem{Label: "expected", Value: g.expected.String()},
+		)
+		items.AppendSummary(buf, prefix)
+		return
+	}
+
+	items.AppendSummary(buf, prefix)
+	buf.WriteByte('\n')
+	buf.WriteString(prefix)
+	buf.WriteString(color.BadOn)
+	buf.WriteString("first one:")
+	buf.WriteString(color.BadOff)
+	buf.WriteByte('\n')
+	g.first.Summary.AppendSummary(buf, prefix+"\t")
+}
+
+// groupErrors groups errors sharing the same message, operator
+// location and path pattern (where array indexes are replaced by
+// "*"). Groups of one error are kept as is. Groups order follows the
+// first occurrence of each group in errors. items is used to know
+// the total number of items behind each path pattern.
+func groupErrors(errors []*Error, items map[string]int) []*Error {
+	var (
+		keys   []groupKey
+		groups = map[groupKey]*errorsGroup{}
+		paths  = map[groupKey]Path{}
+	)
+
+	for _, err := range errors {
+		key, pattern, indexes := errorGroupKey(err)
+
+		g := groups[key]
+		if g == nil {
+			g = &errorsGroup{first: err}
+			groups[key] = g
+			paths[key] = pattern
+			keys = append(keys, key)
+		}
+		g.add(err, indexes)
+	}
+
+	if len(keys) == len(errors) {
+		return errors
+	}
+
+	grouped := make([]*Error, 0, len(keys))
+	for _, key := range keys {
+		g := groups[key]
+		if g.num == 1 {
+			grouped = append(grouped, g.first)
+			continue
+		}
+
+		// Total number of items behind the last array index of the pattern
+		pattern := paths[key]
+		for i := len(pattern) - 1; i >= 0; i-- {
+			if pattern[i].Kind == levelArray {
+				g.total = items[itemsKey(pattern[:i+1])]
+				break
+			}
+		}
+
+		err := *g.first
+		err.Context.Path = pattern
+		err.Summary = g
+		err.Got, err.Expected = nil, nil
+		err.Next = nil
+		grouped = append(grouped, &err)
+	}
+	return grouped
+}
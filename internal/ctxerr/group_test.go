@@ -0,0 +1,153 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package ctxerr_test
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/internal/color"
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/location"
+	"github.com/maxatome/go-testdeep/internal/test"
+)
+
+func TestPathGeneralize(t *testing.T) {
+	path, indexes := ctxerr.NewPath("DATA").
+		AddArrayIndex(3).
+		AddField("Items").
+		AddArrayIndex(12).
+		AddMapKey("key").
+		Generalize()
+	test.EqualStr(t, path.String(), `DATA[*].Items[*]["key"]`)
+	test.EqualInt(t, len(indexes), 2)
+	test.EqualStr(t, indexes[0], "3")
+	test.EqualStr(t, indexes[1], "12")
+
+	path, indexes = ctxerr.NewPath("DATA").AddField("Foo").Generalize()
+	test.EqualStr(t, path.String(), "DATA.Foo")
+	test.EqualInt(t, len(indexes), 0)
+}
+
+func TestGroupErrors(t *testing.T) {
+	defer color.SaveState()()
+
+	loc := location.Location{File: "file.go", Func: "ArrayEach", Line: 12}
+
+	newCtx := func(maxErrors int) ctxerr.Context {
+		ctx := ctxerr.Context{
+			Path:        ctxerr.NewPath("DATA"),
+			MaxErrors:   maxErrors,
+			GroupErrors: true,
+		}
+		ctx.InitErrors()
+		return ctx
+	}
+
+	// fill returns the error returned by CollectError when it stops
+	// the traversal, else the merged errors
+	fill := func(ctx ctxerr.Context) *ctxerr.Error {
+		for i := 0; i < 10; i++ {
+			ictx := ctx.AddArrayIndex(i)
+			if i == 4 {
+				continue // OK item
+			}
+			got := "pending"
+			if i == 7 {
+				got = "failed"
+			}
+			err := ictx.AddField("Status").CollectError(&ctxerr.Error{
+				Message:  "values differ",
+				Got:      got,
+				Expected: "done",
+				Location: loc,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		// Same location, but another message
+		err := ctx.AddField("Count").CollectError(&ctxerr.Error{
+			Message:  "values differ",
+			Got:      1,
+			Expected: 2,
+			Location: loc,
+		})
+		if err != nil {
+			return err
+		}
+		// Summary-based group
+		for i := 0; i < 2; i++ {
+			err := ctx.AddField("Tags").AddArrayIndex(i).CollectError(&ctxerr.Error{
+				Message:  "comparing slices",
+				Summary:  ctxerr.NewSummary("Missing: 1 item"),
+				Location: loc,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return ctx.MergeErrors()
+	}
+
+	// Items visited in boolean context are not counted
+	ctx := newCtx(10)
+	bctx := ctx
+	bctx.BooleanError = true
+	for i := 0; i < 5; i++ {
+		bctx.AddArrayIndex(i)
+	}
+	test.EqualStr(t, fill(ctx).Error(), `DATA[*].Status: values differ
+	   items: 9 of 10
+	 indexes: [0], [1], [2], [3], [5], …
+	     got: "pending" (×8)
+	          "failed" (×1)
+	expected: "done" (×9)
+DATA.Count: values differ
+	     got: 1
+	expected: 2
+DATA.Tags[*]: comparing slices
+	  items: 2 of 2
+	indexes: [0], [1]
+	first one:
+		Missing: 1 item
+[under operator ArrayEach at file.go:12]`)
+
+	// MaxErrors applies to groups: the traversal stops as soon as a
+	// third group appears
+	test.EqualStr(t, fill(newCtx(2)).Error(), `DATA[*].Status: values differ
+	   items: 9 of 10
+	 indexes: [0], [1], [2], [3], [5], …
+	     got: "pending" (×8)
+	          "failed" (×1)
+	expected: "done" (×9)
+DATA.Count: values differ
+	     got: 1
+	expected: 2
+[under operator ArrayEach at file.go:12]
+Too many errors (use TESTDEEP_MAX_ERRORS=-1 to see all)`)
+
+	// Stops at the first error
+	for _, maxErrors := range []int{0, 1} {
+		test.EqualStr(t, fill(newCtx(maxErrors)).Error(), `DATA[0].Status: values differ
+	     got: "pending"
+	expected: "done"
+[under operator ArrayEach at file.go:12]`)
+	}
+
+	// Nothing to group
+	ctx = newCtx(-1)
+	ctx.AddArrayIndex(1).CollectError(&ctxerr.Error{
+		Message:  "values differ",
+		Got:      1,
+		Expected: 2,
+	})
+	test.EqualStr(t, ctx.MergeErrors().Error(), `DATA[1]: values differ
+	     got: 1
+	expected: 2`)
+
+	test.IsTrue(t, newCtx(-1).MergeErrors() == nil)
+}
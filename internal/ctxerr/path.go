@@ -7,12 +7,29 @@
 package ctxerr
 
 import (
+	"reflect"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/maxatome/go-testdeep/internal/util"
 )
 
+// PathStyle defines how a [Path] is rendered. See [Path.StringStyle].
+type PathStyle uint8
+
+const (
+	// PathStyleGo renders a [Path] as a Go expression, as in
+	// DATA.Foo[3]["key"]*.
+	PathStyleGo PathStyle = iota
+	// PathStyleJSONPointer renders a [Path] as a RFC 6901 JSON
+	// pointer, as in /foo/3/key.
+	PathStyleJSONPointer
+	// PathStyleJSONPath renders a [Path] as a JSONPath expression, as
+	// in $.foo[3].key.
+	PathStyleJSONPath
+)
+
 // Path defines a structure depth path, typically used to mark a
 // position during a deep traversal in case of error.
 type Path []pathLevel
@@ -21,16 +38,25 @@ type pathLevelKind uint8
 
 type pathLevel struct {
 	Content  string
+	Name     string // if not empty, used instead of Content by JSON styles
 	Pointers int
 	Kind     pathLevelKind
 }
 
+func (l pathLevel) name() string {
+	if l.Name != "" {
+		return l.Name
+	}
+	return l.Content
+}
+
 const (
 	levelStruct pathLevelKind = iota
 	levelArray
 	levelMap
 	levelFunc
 	levelCustom
+	levelExpr
 )
 
 // NewPath returns a new [Path] initialized with root root node.
@@ -43,6 +69,18 @@ func NewPath(root string) Path {
 	}
 }
 
+// NewExprPath returns a new [Path] initialized with expr root
+// node. Contrary to [NewPath] root node, expr is rendered by all
+// styles, see [Path.StringStyle].
+func NewExprPath(expr string) Path {
+	return Path{
+		{
+			Kind:    levelExpr,
+			Content: expr,
+		},
+	}
+}
+
 // Len returns the number of levels, excluding pointers ones.
 func (p Path) Len() int {
 	return len(p)
@@ -96,6 +134,30 @@ func (p Path) AddField(field string) Path {
 	return new
 }
 
+// AddStructField adds a level corresponding to a struct field. Contrary
+// to [Path.AddField], the JSON name of the field is recorded, so it is
+// used by [PathStyleJSONPointer] and [PathStyleJSONPath] styles.
+func (p Path) AddStructField(field reflect.StructField) Path {
+	new := p.AddField(field.Name)
+	if new != nil {
+		new[len(new)-1].Name = jsonFieldName(field)
+	}
+	return new
+}
+
+// jsonFieldName returns the name of field as encoding/json uses it.
+func jsonFieldName(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		if comma := strings.IndexByte(tag, ','); comma >= 0 {
+			tag = tag[:comma]
+		}
+		if tag != "" && tag != "-" {
+			return tag
+		}
+	}
+	return field.Name
+}
+
 // AddArrayIndex adds a level corresponding to an array index.
 func (p Path) AddArrayIndex(index int) Path {
 	if p == nil {
@@ -117,9 +179,37 @@ func (p Path) AddMapKey(key any) Path {
 	return p.addLevel(pathLevel{
 		Kind:    levelMap,
 		Content: util.ToString(key),
+		Name:    mapKeyName(key),
 	})
 }
 
+// mapKeyName returns the raw string representation of key if it is a
+// string, a bool or a number. Otherwise it returns "".
+func mapKeyName(key any) string {
+	v, ok := key.(reflect.Value)
+	if !ok {
+		v = reflect.ValueOf(key)
+	}
+	for v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, 64)
+	}
+	return ""
+}
+
 // AddPtr adds num pointers levels.
 func (p Path) AddPtr(num int) Path {
 	if p == nil {
@@ -192,3 +282,83 @@ func (p Path) String() string {
 
 	return str
 }
+
+var (
+	jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+	jsonPathEscaper    = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+)
+
+// StringStyle returns p rendered using style.
+//
+// With [PathStyleJSONPointer] and [PathStyleJSONPath] styles,
+// pointers are not rendered, and struct fields recorded using
+// [Path.AddStructField] are rendered using their JSON name. As these
+// styles have no equivalent for custom and function levels, custom
+// levels are rendered as is and function levels as with
+// [PathStyleGo]. With [PathStyleJSONPointer] the root level is never
+// rendered, so the root alone is rendered as "", the RFC 6901 pointer
+// of the whole document. With [PathStyleJSONPath] it is rendered as
+// "$". Root levels created by [NewExprPath] are the exception, they
+// are always rendered as is.
+func (p Path) StringStyle(style PathStyle) string {
+	if len(p) == 0 {
+		return ""
+	}
+
+	var str string
+
+	switch style {
+	case PathStyleJSONPointer:
+		for i, level := range p {
+			switch level.Kind {
+			case levelStruct, levelArray, levelMap:
+				str += "/" + jsonPointerEscaper.Replace(level.name())
+			case levelFunc:
+				str = level.Content + "(" + str + ")"
+			default:
+				if i > 0 || level.Kind == levelExpr {
+					str += level.Content
+				}
+			}
+		}
+	case PathStyleJSONPath:
+		for i, level := range p {
+			switch level.Kind {
+			case levelStruct, levelMap:
+				name := level.name()
+				if isJSONPathIdent(name) {
+					str += "." + name
+				} else {
+					str += "['" + jsonPathEscaper.Replace(name) + "']"
+				}
+			case levelArray:
+				str += "[" + level.Content + "]"
+			case levelFunc:
+				str = level.Content + "(" + str + ")"
+			default:
+				if i == 0 && level.Kind != levelExpr {
+					str = "$"
+				} else {
+					str += level.Content
+				}
+			}
+		}
+
+	default:
+		return p.String()
+	}
+
+	return str
+}
+
+func isJSONPathIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
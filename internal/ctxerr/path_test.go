@@ -7,6 +7,7 @@
 package ctxerr_test
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/maxatome/go-testdeep/internal/ctxerr"
@@ -185,6 +186,87 @@ func TestPath(t *testing.T) {
 	}
 }
 
+func TestPathStringStyle(t *testing.T) {
+	type S struct {
+		Foo  int `json:"foo,omitempty"`
+		Bar  int `json:",omitempty"`
+		Zip  int `json:"-"`
+		Name int
+	}
+	st := reflect.TypeOf(S{})
+
+	path := ctxerr.NewPath("DATA").
+		AddPtr(1).
+		AddStructField(st.Field(0)).
+		AddArrayIndex(3).
+		AddMapKey("key").
+		AddPtr(2)
+
+	test.EqualStr(t, path.StringStyle(ctxerr.PathStyleGo), `**DATA.Foo[3]["key"]`)
+	test.EqualStr(t, path.StringStyle(ctxerr.PathStyleJSONPointer), "/foo/3/key")
+	test.EqualStr(t, path.StringStyle(ctxerr.PathStyleJSONPath), "$.foo[3].key")
+
+	for i, testCase := range []struct {
+		Path        ctxerr.Path
+		JSONPointer string
+		JSONPath    string
+	}{
+		{
+			Path:        ctxerr.Path{},
+			JSONPointer: "",
+			JSONPath:    "",
+		},
+		{
+			Path:        ctxerr.NewPath("DATA"),
+			JSONPointer: "",
+			JSONPath:    "$",
+		},
+		{
+			Path: ctxerr.NewPath("DATA").
+				AddStructField(st.Field(1)).
+				AddStructField(st.Field(2)).
+				AddStructField(st.Field(3)).
+				AddField("Raw"),
+			JSONPointer: "/Bar/Zip/Name/Raw",
+			JSONPath:    "$.Bar.Zip.Name.Raw",
+		},
+		{
+			Path: ctxerr.NewPath("DATA").
+				AddMapKey("a/b~c").
+				AddMapKey("it's").
+				AddMapKey(12).
+				AddMapKey(reflect.ValueOf(true)).
+				AddMapKey(1.5).
+				AddMapKey(uint8(4)),
+			JSONPointer: "/a~1b~0c/it's/12/true/1.5/4",
+			JSONPath:    `$['a/b~c']['it\'s']['12'].true['1.5']['4']`,
+		},
+		{
+			Path: ctxerr.NewPath("DATA").
+				AddField("Foo").
+				AddFunctionCall("len").
+				AddCustomLevel("<All#1/2>").
+				AddArrayIndex(1),
+			JSONPointer: "len(/Foo)<All#1/2>/1",
+			JSONPath:    "len($.Foo)<All#1/2>[1]",
+		},
+		{
+			Path:        ctxerr.NewExprPath("(/Foo =~ re)").AddArrayIndex(1),
+			JSONPointer: "(/Foo =~ re)/1",
+			JSONPath:    "(/Foo =~ re)[1]",
+		},
+	} {
+		test.EqualStr(t,
+			testCase.Path.StringStyle(ctxerr.PathStyleJSONPointer),
+			testCase.JSONPointer,
+			"JSON pointer test case #%d", i)
+		test.EqualStr(t,
+			testCase.Path.StringStyle(ctxerr.PathStyleJSONPath),
+			testCase.JSONPath,
+			"JSONPath test case #%d", i)
+	}
+}
+
 func TestEqual(t *testing.T) {
 	path := ctxerr.NewPath("DATA").
 		AddPtr(2).
@@ -0,0 +1,189 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package ctxerr
+
+import (
+	"strconv"
+	"strings"
+)
+
+type patternKind uint8
+
+const (
+	patternName    patternKind = iota // .Name or root name
+	patternKey                        // [12] or ["key"] or [key]
+	patternAny                        // * or [*], any single level
+	patternAnyDeep                    // **, zero or more levels
+)
+
+type patternSegment struct {
+	kind  patternKind
+	value string // unquoted value
+	raw   string // value as written, for non-string map keys
+}
+
+// PathPattern is a glob-like [Path] pattern. See [ParsePathPattern].
+type PathPattern []patternSegment
+
+// ParsePathPattern parses pattern and returns the corresponding
+// [PathPattern]. A pattern is a path as displayed in errors, where:
+//   - "*" (as in DATA.*.CreatedAt or DATA.Items[*]) matches any
+//     single level;
+//   - "**" (as in **.UpdatedAt or DATA.**.ID) matches zero or more
+//     levels.
+//
+// The first segment of pattern matches the root of the path, as
+// "DATA". ".Name" matches a struct field or a map key, "[12]" an
+// array index or a map key, and ["key"] or [key] a map key. Pointers
+// are ignored, as well as function and custom levels after the root
+// one.
+//
+// Parsing never fails: an unterminated "[" is taken as the beginning
+// of a map key spanning until the end of pattern.
+func ParsePathPattern(pattern string) PathPattern {
+	var pp PathPattern
+
+	newSegment := func(kind patternKind, value string) {
+		switch value {
+		case "*":
+			kind = patternAny
+		case "**":
+			kind = patternAnyDeep
+		}
+		seg := patternSegment{kind: kind, value: value, raw: value}
+		if kind == patternKey && strings.HasPrefix(value, `"`) {
+			if s, err := strconv.Unquote(value); err == nil {
+				seg.value = s
+			}
+		}
+		pp = append(pp, seg)
+	}
+
+	// Root
+	end := strings.IndexAny(pattern, ".[")
+	if end < 0 {
+		end = len(pattern)
+	}
+	newSegment(patternName, pattern[:end])
+	pattern = pattern[end:]
+
+	for pattern != "" {
+		if pattern[0] == '.' {
+			pattern = pattern[1:]
+			end := strings.IndexAny(pattern, ".[")
+			if end < 0 {
+				end = len(pattern)
+			}
+			newSegment(patternName, pattern[:end])
+			pattern = pattern[end:]
+			continue
+		}
+
+		// pattern[0] == '['
+		pattern = pattern[1:]
+		end := closingBracket(pattern)
+		newSegment(patternKey, pattern[:end])
+		if end < len(pattern) {
+			end++ // skip ]
+		}
+		pattern = pattern[end:]
+	}
+	return pp
+}
+
+// closingBracket returns the position of the "]" ending s, skipping
+// double-quoted strings, or len(s) if not found.
+func closingBracket(s string) int {
+	inString := false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if inString {
+				i++
+			}
+		case '"':
+			inString = !inString
+		case ']':
+			if !inString {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+// ParsePathPatterns parses all patterns using [ParsePathPattern]. It
+// returns nil if patterns is empty.
+func ParsePathPatterns(patterns []string) []PathPattern {
+	if len(patterns) == 0 {
+		return nil
+	}
+	pps := make([]PathPattern, len(patterns))
+	for i, pattern := range patterns {
+		pps[i] = ParsePathPattern(pattern)
+	}
+	return pps
+}
+
+// Match returns true if p matches pp.
+func (pp PathPattern) Match(p Path) bool {
+	if len(p) == 0 {
+		return false
+	}
+
+	// Only keep data levels after the root
+	levels := make(Path, 1, len(p))
+	levels[0] = p[0]
+	for _, level := range p[1:] {
+		if level.Kind != levelFunc && level.Kind != levelCustom {
+			levels = append(levels, level)
+		}
+	}
+	return pp.match(levels)
+}
+
+func (pp PathPattern) match(p Path) bool {
+	for len(pp) > 0 {
+		seg := pp[0]
+		if seg.kind == patternAnyDeep {
+			for i := 0; i <= len(p); i++ {
+				if pp[1:].match(p[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(p) == 0 || !seg.matchLevel(p[0]) {
+			return false
+		}
+		pp, p = pp[1:], p[1:]
+	}
+	return len(p) == 0
+}
+
+func (seg patternSegment) matchLevel(level pathLevel) bool {
+	switch seg.kind {
+	case patternAny:
+		return true
+	case patternName:
+		switch level.Kind {
+		case levelStruct, levelCustom:
+			return level.Content == seg.value
+		case levelMap:
+			return level.name() == seg.value
+		}
+	case patternKey:
+		switch level.Kind {
+		case levelArray:
+			return level.Content == seg.value
+		case levelMap:
+			return level.Content == seg.raw ||
+				(level.Name != "" && level.Name == seg.value)
+		}
+	}
+	return false
+}
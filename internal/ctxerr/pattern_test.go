@@ -0,0 +1,71 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package ctxerr_test
+
+import (
+	"testing"
+
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/test"
+)
+
+func TestPathPattern(t *testing.T) {
+	root := ctxerr.NewPath("DATA")
+	items := root.AddField("Items").AddPtr(1).AddArrayIndex(3)
+
+	for _, tc := range []struct {
+		pattern string
+		path    ctxerr.Path
+		match   bool
+	}{
+		{pattern: "DATA", path: root, match: true},
+		{pattern: "BODY", path: root},
+		{pattern: "DATA.Items", path: root},
+		{pattern: "*", path: root, match: true},
+		{pattern: "**", path: root, match: true},
+		{pattern: "DATA.Items[*].ID", path: items.AddField("ID"), match: true},
+		{pattern: "DATA.Items[3].ID", path: items.AddField("ID"), match: true},
+		{pattern: "DATA.Items[2].ID", path: items.AddField("ID")},
+		{pattern: "DATA.Items[*].ID", path: items.AddField("IDs")},
+		{pattern: "DATA.Items[*].ID", path: items},
+		{pattern: "DATA.*.*.ID", path: items.AddField("ID"), match: true},
+		{pattern: "DATA.*.ID", path: items.AddField("ID")},
+		{pattern: "**.ID", path: items.AddField("ID"), match: true},
+		{pattern: "**.ID", path: root.AddField("ID"), match: true},
+		{pattern: "**.ID", path: items.AddField("ID").AddField("X")},
+		{pattern: "DATA.**.ID", path: root.AddField("ID"), match: true},
+		{pattern: "DATA.**", path: items, match: true},
+		{pattern: "**.Items.**.ID", path: items.AddField("ID"), match: true},
+		// Map keys
+		{pattern: `DATA.Meta["created_at"]`, path: root.AddField("Meta").AddMapKey("created_at"), match: true},
+		{pattern: `DATA.Meta[created_at]`, path: root.AddField("Meta").AddMapKey("created_at"), match: true},
+		{pattern: `DATA.Meta.created_at`, path: root.AddField("Meta").AddMapKey("created_at"), match: true},
+		{pattern: `DATA.Meta["a.b[c]"]`, path: root.AddField("Meta").AddMapKey("a.b[c]"), match: true},
+		{pattern: `DATA[12].X`, path: root.AddMapKey(12).AddField("X"), match: true},
+		{pattern: `DATA[*].X`, path: root.AddMapKey(12).AddField("X"), match: true},
+		{pattern: `DATA[true]`, path: root.AddMapKey(true), match: true},
+		{pattern: `DATA["unterminated`, path: root.AddMapKey("unterminated")},
+		{pattern: `DATA[unterminated`, path: root.AddMapKey("unterminated"), match: true},
+		// Function and custom levels are ignored
+		{pattern: "DATA.Name", path: root.AddCustomLevel("<All#1/2>").AddField("Name"), match: true},
+		{pattern: "DATA.Name", path: root.AddFunctionCall("len").AddField("Name"), match: true},
+	} {
+		test.EqualBool(t, ctxerr.ParsePathPattern(tc.pattern).Match(tc.path), tc.match,
+			"%s vs %s", tc.pattern, tc.path)
+	}
+
+	test.IsFalse(t, ctxerr.ParsePathPattern("**").Match(nil))
+	test.IsTrue(t, ctxerr.ParsePathPatterns(nil) == nil)
+
+	ctx := ctxerr.Context{
+		Path:        root.AddField("Items"),
+		IgnorePaths: ctxerr.ParsePathPatterns([]string{"DATA.Foo", "DATA.Items"}),
+	}
+	test.IsTrue(t, ctx.IsIgnoredPath())
+	ctx.Path = root.AddField("Bar")
+	test.IsFalse(t, ctx.IsIgnoredPath())
+}
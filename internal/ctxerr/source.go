@@ -0,0 +1,189 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package ctxerr
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/maxatome/go-testdeep/internal/color"
+	"github.com/maxatome/go-testdeep/internal/location"
+	"github.com/maxatome/go-testdeep/internal/trace"
+)
+
+// MaxSourceLines is the maximum number of source lines displayed
+// around an operator location. See [Context.SourceLines].
+const MaxSourceLines = 3
+
+type sourceFile struct {
+	name  string // file name relative to its module directory
+	lines []string
+}
+
+var sourceCache = struct {
+	sync.Mutex
+	files map[string]*sourceFile
+}{
+	files: map[string]*sourceFile{},
+}
+
+// ResetSourceCache empties the source files cache. Only intended to
+// be used in go-testdeep internal tests.
+func ResetSourceCache() {
+	sourceCache.Lock()
+	sourceCache.files = map[string]*sourceFile{}
+	sourceCache.Unlock()
+}
+
+// loadSource returns the lines of file path, reading it only the
+// first time it is requested. It returns nil if path cannot be read,
+// and this result is cached as well.
+func loadSource(path string) *sourceFile {
+	sourceCache.Lock()
+	defer sourceCache.Unlock()
+
+	sf, ok := sourceCache.files[path]
+	if ok {
+		return sf
+	}
+
+	fh, err := os.Open(path)
+	if err == nil {
+		defer fh.Close()
+
+		sf = &sourceFile{name: path}
+		if modDir := trace.FindGoModDir(filepath.Dir(path)); modDir != "" {
+			sf.name = strings.TrimPrefix(path, modDir)
+		}
+
+		scanner := bufio.NewScanner(fh)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			sf.lines = append(sf.lines, scanner.Text())
+		}
+		if scanner.Err() != nil {
+			sf = nil
+		}
+	}
+
+	sourceCache.files[path] = sf
+	return sf
+}
+
+// caretColumn returns the byte offset of the fn call in line, or -1
+// if not found.
+func caretColumn(line, fn string) int {
+	if fn == "" {
+		return -1
+	}
+	for start := 0; ; {
+		pos := strings.Index(line[start:], fn+"(")
+		if pos < 0 {
+			return -1
+		}
+		pos += start
+		// Ensure fn is not the suffix of another identifier
+		if pos == 0 || !isIdentByte(line[pos-1]) {
+			return pos
+		}
+		start = pos + len(fn)
+	}
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' ||
+		(b >= 'a' && b <= 'z') ||
+		(b >= 'A' && b <= 'Z') ||
+		(b >= '0' && b <= '9')
+}
+
+// appendSource appends to buf up to num source lines around loc,
+// with a caret under the operator call. Each line is prefixed by
+// prefix. Nothing is appended if the source file cannot be read.
+//
+// num lines are displayed as follows: 1 → only the operator line; 2
+// → the previous line plus the operator one; 3 → the previous, the
+// operator and the next lines.
+func appendSource(buf *bytes.Buffer, prefix string, loc location.Location, num int) {
+	if num <= 0 || loc.FullPath == "" || loc.Line <= 0 {
+		return
+	}
+	if num > MaxSourceLines {
+		num = MaxSourceLines
+	}
+
+	sf := loadSource(loc.FullPath)
+	if sf == nil || loc.Line > len(sf.lines) {
+		return
+	}
+
+	first := loc.Line - num/2
+	if first < 1 {
+		first = 1
+	}
+	last := first + num - 1
+	if last > len(sf.lines) {
+		last = len(sf.lines)
+	}
+
+	width := len(strconv.Itoa(last))
+	gutter := strings.Repeat(" ", width)
+
+	buf.WriteByte('\n')
+	buf.WriteString(prefix)
+	buf.WriteString(sf.name)
+	buf.WriteByte(':')
+
+	for n := first; n <= last; n++ {
+		line := sf.lines[n-1]
+
+		buf.WriteByte('\n')
+		buf.WriteString(prefix)
+		sn := strconv.Itoa(n)
+		buf.WriteString(gutter[len(sn):])
+		buf.WriteString(sn)
+		buf.WriteString(" | ")
+		buf.WriteString(line)
+
+		if n == loc.Line {
+			if col := caretColumn(line, loc.Func); col >= 0 {
+				buf.WriteByte('\n')
+				buf.WriteString(prefix)
+				buf.WriteString(gutter)
+				buf.WriteString(" | ")
+				// Keep tabs so the caret is aligned whatever the tab width
+				for _, r := range line[:col] {
+					if r == '\t' {
+						buf.WriteByte('\t')
+					} else {
+						buf.WriteByte(' ')
+					}
+				}
+				buf.WriteString(color.BadOnBold)
+				buf.WriteByte('^')
+				buf.WriteString(color.BadOff)
+			}
+		}
+	}
+}
+
+// SourceString returns up to num source lines around loc, as
+// displayed by [Error.Append] when [Context.SourceLines] is num, or ""
+// if the source file cannot be read.
+func SourceString(loc location.Location, num int) string {
+	var buf bytes.Buffer
+	appendSource(&buf, "", loc, num)
+	if buf.Len() == 0 {
+		return ""
+	}
+	return buf.String()[1:] // skip leading \n
+}
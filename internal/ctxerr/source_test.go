@@ -0,0 +1,138 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package ctxerr_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/internal/color"
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/location"
+	"github.com/maxatome/go-testdeep/internal/test"
+)
+
+func TestErrorSource(t *testing.T) {
+	defer color.SaveState()()
+	defer ctxerr.ResetSourceCache()
+
+	dir, err := ioutil.TempDir("", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"),
+		[]byte("module foo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "bar"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "bar", "foo_test.go")
+	if err := ioutil.WriteFile(file, []byte(`package bar
+
+func TestFoo(t *testing.T) {
+	td.Cmp(t, got, td.Struct(Person{}, td.StructFields{
+		"Children": td.ArrayEach(td.Gt(0)),
+	}))
+}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	newErr := func(line, num int) *ctxerr.Error {
+		return &ctxerr.Error{
+			Context: ctxerr.Context{
+				Path:        ctxerr.NewPath("DATA").AddField("Children"),
+				SourceLines: num,
+			},
+			Message:  "error message",
+			Got:      1,
+			Expected: 2,
+			Location: location.Location{
+				File:     "foo_test.go",
+				FullPath: file,
+				Func:     "ArrayEach",
+				Line:     line,
+			},
+		}
+	}
+
+	test.EqualStr(t, newErr(5, 0).Error(),
+		`DATA.Children: error message
+	     got: 1
+	expected: 2
+[under operator ArrayEach at foo_test.go:5]`)
+
+	test.EqualStr(t, newErr(5, 1).Error(),
+		`DATA.Children: error message
+	     got: 1
+	expected: 2
+[under operator ArrayEach at foo_test.go:5]
+	bar/foo_test.go:
+	5 | 		"Children": td.ArrayEach(td.Gt(0)),
+	  | 		               ^`)
+
+	test.EqualStr(t, newErr(5, 3).Error(),
+		`DATA.Children: error message
+	     got: 1
+	expected: 2
+[under operator ArrayEach at foo_test.go:5]
+	bar/foo_test.go:
+	4 | 	td.Cmp(t, got, td.Struct(Person{}, td.StructFields{
+	5 | 		"Children": td.ArrayEach(td.Gt(0)),
+	  | 		               ^
+	6 | 	}))`)
+
+	// Capped to 3 lines, and truncated at end of file
+	test.EqualStr(t, newErr(7, 12).Error(),
+		`DATA.Children: error message
+	     got: 1
+	expected: 2
+[under operator ArrayEach at foo_test.go:7]
+	bar/foo_test.go:
+	6 | 	}))
+	7 | }`)
+
+	// Operator not found in line: no caret
+	test.EqualStr(t, newErr(1, 2).Error(),
+		`DATA.Children: error message
+	     got: 1
+	expected: 2
+[under operator ArrayEach at foo_test.go:1]
+	bar/foo_test.go:
+	1 | package bar
+	2 | `)
+
+	// Unknown line
+	test.EqualStr(t, newErr(100, 3).Error(),
+		`DATA.Children: error message
+	     got: 1
+	expected: 2
+[under operator ArrayEach at foo_test.go:100]`)
+
+	// File cannot be read, even once removed from disk after being cached
+	os.Remove(file)
+	test.EqualStr(t, newErr(5, 1).Error(),
+		`DATA.Children: error message
+	     got: 1
+	expected: 2
+[under operator ArrayEach at foo_test.go:5]
+	bar/foo_test.go:
+	5 | 		"Children": td.ArrayEach(td.Gt(0)),
+	  | 		               ^`)
+
+	ctxerr.ResetSourceCache()
+	test.EqualStr(t, newErr(5, 1).Error(),
+		`DATA.Children: error message
+	     got: 1
+	expected: 2
+[under operator ArrayEach at foo_test.go:5]`)
+}
@@ -20,12 +20,30 @@ type properties struct {
 	smuggle          reflect.Value
 	ignoreUnexported bool
 	useEqual         bool
+	equateEmpty      bool
+	ignoreSliceOrder bool
+	mapKeyNormalizer reflect.Value
+}
+
+// ifaceHooks contains the hooks recorded for an interface type.
+type ifaceHooks struct {
+	typ     reflect.Type
+	cmp     reflect.Value
+	smuggle reflect.Value
+}
+
+// conversion is a converter to type to.
+type conversion struct {
+	to reflect.Type
+	fn reflect.Value
 }
 
 // Info gathers all hooks information.
 type Info struct {
 	sync.Mutex
-	props map[reflect.Type]properties
+	props  map[reflect.Type]properties
+	ifaces []ifaceHooks                  // in recording order
+	convs  map[reflect.Type][]conversion // from type → converters in recording order
 }
 
 // NewInfo returns a new instance of *Info.
@@ -49,7 +67,7 @@ func (i *Info) Copy() *Info {
 	i.Lock()
 	defer i.Unlock()
 
-	if len(i.props) == 0 {
+	if len(i.props) == 0 && len(i.ifaces) == 0 && len(i.convs) == 0 {
 		return ni
 	}
 
@@ -57,10 +75,91 @@ func (i *Info) Copy() *Info {
 	for t, p := range i.props {
 		ni.props[t] = p
 	}
+	ni.ifaces = append(ni.ifaces, i.ifaces...)
+	if len(i.convs) > 0 {
+		ni.convs = make(map[reflect.Type][]conversion, len(i.convs))
+		for t, c := range i.convs {
+			ni.convs[t] = append([]conversion(nil), c...)
+		}
+	}
 
 	return ni
 }
 
+// Reset removes all hooks of i. As i is modified in place, all
+// holders of i are impacted.
+func (i *Info) Reset() {
+	if i == nil {
+		return
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	i.props = map[reflect.Type]properties{}
+	i.ifaces = nil
+	i.convs = nil
+}
+
+// isNonEmptyInterface returns true if t is an interface with at least
+// one method. The empty interface is refused as hook key, as any type
+// implements it.
+func isNonEmptyInterface(t reflect.Type) bool {
+	return t.Kind() == reflect.Interface && t.NumMethod() > 0
+}
+
+// setIfaceHook records fn as Cmp (if cmp is true) or Smuggle hook of
+// interface t. A previously recorded hook for t is replaced, but
+// keeps its position.
+func (i *Info) setIfaceHook(t reflect.Type, fn reflect.Value, cmp bool) {
+	i.Lock()
+	defer i.Unlock()
+
+	n := 0
+	for ; n < len(i.ifaces); n++ {
+		if i.ifaces[n].typ == t {
+			break
+		}
+	}
+	if n == len(i.ifaces) {
+		i.ifaces = append(i.ifaces, ifaceHooks{typ: t})
+	}
+	if cmp {
+		i.ifaces[n].cmp = fn
+	} else {
+		i.ifaces[n].smuggle = fn
+	}
+}
+
+// hook returns the Cmp (if cmp is true) or Smuggle hook recorded for
+// type t. Hooks recorded for t itself take precedence over those
+// recorded for interfaces t implements, these last ones being
+// examined in their recording order. It returns an invalid
+// [reflect.Value] if no hook is found.
+func (i *Info) hook(t reflect.Type, cmp bool) reflect.Value {
+	i.Lock()
+	defer i.Unlock()
+
+	fn := i.props[t].smuggle
+	if cmp {
+		fn = i.props[t].cmp
+	}
+	if fn.IsValid() {
+		return fn
+	}
+
+	for _, ih := range i.ifaces {
+		fn = ih.smuggle
+		if cmp {
+			fn = ih.cmp
+		}
+		if fn.IsValid() && t.Implements(ih.typ) {
+			return fn
+		}
+	}
+	return reflect.Value{}
+}
+
 // AddCmpHooks records new Cmp hooks using functions contained in fns.
 //
 // Each function in fns has to be a function with the following
@@ -71,8 +170,9 @@ func (i *Info) Copy() *Info {
 //
 // First arg is always “got”, and second is always “expected”.
 //
-// A cannot be an interface. This retriction can be removed in the
-// future, if really needed.
+// A can be a non-empty interface. In this case, the hook is used for
+// all types implementing A, unless a hook is recorded for the
+// concrete type itself.
 //
 // It returns an error if an item of fns is not a function or if its
 // signature does not match the expected ones.
@@ -89,8 +189,13 @@ func (i *Info) AddCmpHooks(fns []any) error {
 			ft.NumIn() == 2 &&
 			ft.NumOut() == 1 &&
 			ft.In(0) == ft.In(1) &&
-			ft.In(0).Kind() != reflect.Interface &&
+			(ft.In(0).Kind() != reflect.Interface || isNonEmptyInterface(ft.In(0))) &&
 			(ft.Out(0) == types.Bool || ft.Out(0) == types.Error) {
+			if ft.In(0).Kind() == reflect.Interface {
+				i.setIfaceHook(ft.In(0), vfn, true)
+				continue
+			}
+
 			i.Lock()
 			prop := i.props[ft.In(0)]
 			prop.cmp = vfn
@@ -116,20 +221,12 @@ func (i *Info) Cmp(got, expected reflect.Value) (bool, error) {
 		return false, nil
 	}
 
-	tg := got.Type()
-
-	i.Lock()
-	prop, ok := i.props[tg]
-	i.Unlock()
-	if !ok || !prop.cmp.IsValid() {
-		return false, nil
-	}
-
-	if !expected.Type().AssignableTo(prop.cmp.Type().In(1)) {
+	fn := i.hook(got.Type(), true)
+	if !fn.IsValid() || !expected.Type().AssignableTo(fn.Type().In(1)) {
 		return false, nil
 	}
 
-	res := prop.cmp.Call([]reflect.Value{got, expected})[0]
+	res := fn.Call([]reflect.Value{got, expected})[0]
 	if res.Kind() == reflect.Bool {
 		if res.Bool() {
 			return true, nil
@@ -149,8 +246,9 @@ func (i *Info) Cmp(got, expected reflect.Value) (bool, error) {
 //	func (got A) B
 //	func (got A) (B, error)
 //
-// A cannot be an interface. This retriction can be removed in the
-// future, if really needed.
+// A can be a non-empty interface. In this case, the hook is used for
+// all types implementing A, unless a hook is recorded for the
+// concrete type itself.
 //
 // B can be an interface.
 //
@@ -167,9 +265,14 @@ func (i *Info) AddSmuggleHooks(fns []any) error {
 		ft := vfn.Type()
 		if !ft.IsVariadic() &&
 			ft.NumIn() == 1 &&
-			ft.In(0).Kind() != reflect.Interface &&
+			(ft.In(0).Kind() != reflect.Interface || isNonEmptyInterface(ft.In(0))) &&
 			(ft.NumOut() == 1 || (ft.NumOut() == 2 && ft.Out(1) == types.Error)) &&
 			ft.Out(0).Kind() != reflect.Interface {
+			if ft.In(0).Kind() == reflect.Interface {
+				i.setIfaceHook(ft.In(0), vfn, false)
+				continue
+			}
+
 			i.Lock()
 			prop := i.props[ft.In(0)]
 			prop.smuggle = vfn
@@ -194,16 +297,12 @@ func (i *Info) Smuggle(got *reflect.Value) (bool, error) {
 		return false, nil
 	}
 
-	tg := got.Type()
-
-	i.Lock()
-	prop, ok := i.props[tg]
-	i.Unlock()
-	if !ok || !prop.smuggle.IsValid() {
+	fn := i.hook(got.Type(), false)
+	if !fn.IsValid() {
 		return false, nil
 	}
 
-	res := prop.smuggle.Call([]reflect.Value{*got})
+	res := fn.Call([]reflect.Value{*got})
 	if len(res) == 2 {
 		if err, _ := res[1].Interface().(error); err != nil {
 			return true, err
@@ -307,3 +406,238 @@ func (i *Info) IgnoreUnexported(t reflect.Type) bool {
 	defer i.Unlock()
 	return i.props[t].ignoreUnexported
 }
+
+// AddEquateEmpty records types of values contained in ts as equating
+// nil and empty values: nil and zero-length slices or maps, nil
+// pointer and pointer to a zero value. ts can also contain
+// [reflect.Type] instances.
+func (i *Info) AddEquateEmpty(ts []any) error {
+	if len(ts) == 0 {
+		return nil
+	}
+
+	for n, typ := range ts {
+		t, ok := typ.(reflect.Type)
+		if !ok {
+			t = reflect.TypeOf(typ)
+			ts[n] = t
+		}
+		if t == nil {
+			return fmt.Errorf("expects a slice, map or pointer type, not nil (@%d)", n)
+		}
+
+		switch t.Kind() {
+		case reflect.Slice, reflect.Map, reflect.Ptr:
+		default:
+			return fmt.Errorf("expects type %s be a slice, a map or a pointer, not a %s (@%d)", t, t.Kind(), n)
+		}
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	for _, typ := range ts {
+		t := typ.(reflect.Type)
+		prop := i.props[t]
+		prop.equateEmpty = true
+		i.props[t] = prop
+	}
+	return nil
+}
+
+// EquateEmpty returns true if nil and empty values of the type t have
+// to be considered equal.
+func (i *Info) EquateEmpty(t reflect.Type) bool {
+	if i == nil {
+		return false
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	return i.props[t].equateEmpty
+}
+
+// AddIgnoreSliceOrder records types of values contained in ts as
+// slices items types whose order has to be ignored. ts can also
+// contain [reflect.Type] instances.
+func (i *Info) AddIgnoreSliceOrder(ts []any) error {
+	if len(ts) == 0 {
+		return nil
+	}
+
+	for n, typ := range ts {
+		t, ok := typ.(reflect.Type)
+		if !ok {
+			t = reflect.TypeOf(typ)
+			if t == nil {
+				return fmt.Errorf("expects a type, not nil (@%d)", n)
+			}
+			ts[n] = t
+		}
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	for _, typ := range ts {
+		t := typ.(reflect.Type)
+		prop := i.props[t]
+		prop.ignoreSliceOrder = true
+		i.props[t] = prop
+	}
+	return nil
+}
+
+// IgnoreSliceOrder returns true if the order of slices whose items
+// are of type t has to be ignored.
+func (i *Info) IgnoreSliceOrder(t reflect.Type) bool {
+	if i == nil {
+		return false
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	return i.props[t].ignoreSliceOrder
+}
+
+// AddConversions records new converters using functions contained in
+// fns.
+//
+// Each function in fns has to be a function with the following
+// possible signatures:
+//
+//	func (got A) B
+//	func (got A) (B, error)
+//
+// A and B have to be different types. Recording a new converter for
+// the same A and B types replaces the previous one.
+//
+// It returns an error if an item of fns is not a function or if its
+// signature does not match the expected ones.
+func (i *Info) AddConversions(fns []any) error {
+	for n, fn := range fns {
+		vfn := reflect.ValueOf(fn)
+
+		if vfn.Kind() != reflect.Func {
+			return fmt.Errorf("expects a function, not a %s (@%d)", vfn.Kind(), n)
+		}
+
+		ft := vfn.Type()
+		if ft.IsVariadic() ||
+			ft.NumIn() != 1 ||
+			(ft.NumOut() != 1 && (ft.NumOut() != 2 || ft.Out(1) != types.Error)) ||
+			ft.In(0) == ft.Out(0) {
+			return fmt.Errorf("expects: func (A) (B[, error]) with A ≠ B not %s (@%d)", ft, n)
+		}
+
+		from, to := ft.In(0), ft.Out(0)
+
+		i.Lock()
+		if i.convs == nil {
+			i.convs = map[reflect.Type][]conversion{}
+		}
+		// Never modify a slice in place, as it can be shared with a copy
+		convs := make([]conversion, 0, len(i.convs[from])+1)
+		for _, c := range i.convs[from] {
+			if c.to != to {
+				convs = append(convs, c)
+			}
+		}
+		i.convs[from] = append(convs, conversion{to: to, fn: vfn})
+		i.Unlock()
+	}
+	return nil
+}
+
+// Conversion returns the chain of converters allowing to convert a
+// from value to a to value, using at most maxHops converters. It
+// returns nil if no such chain exists. Shortest chains are preferred,
+// then converters recorded first.
+func (i *Info) Conversion(from, to reflect.Type, maxHops int) []reflect.Value {
+	if i == nil {
+		return nil
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	if len(i.convs) == 0 {
+		return nil
+	}
+
+	type step struct {
+		typ   reflect.Type
+		chain []reflect.Value
+	}
+	seen := map[reflect.Type]bool{from: true}
+	steps := []step{{typ: from}}
+	for hop := 0; hop < maxHops && len(steps) > 0; hop++ {
+		var next []step
+		for _, st := range steps {
+			for _, c := range i.convs[st.typ] {
+				if seen[c.to] {
+					continue
+				}
+				n := len(st.chain)
+				chain := append(st.chain[:n:n], c.fn)
+				if c.to == to {
+					return chain
+				}
+				seen[c.to] = true
+				next = append(next, step{typ: c.to, chain: chain})
+			}
+		}
+		steps = next
+	}
+	return nil
+}
+
+// AddMapKeyNormalizers records new map keys normalizers using
+// functions contained in fns.
+//
+// Each function in fns has to be a function with the following
+// signature:
+//
+//	func (key K) K
+//
+// It returns an error if an item of fns is not a function or if its
+// signature does not match the expected one.
+func (i *Info) AddMapKeyNormalizers(fns []any) error {
+	for n, fn := range fns {
+		vfn := reflect.ValueOf(fn)
+
+		if vfn.Kind() != reflect.Func {
+			return fmt.Errorf("expects a function, not a %s (@%d)", vfn.Kind(), n)
+		}
+
+		ft := vfn.Type()
+		if ft.IsVariadic() ||
+			ft.NumIn() != 1 ||
+			ft.NumOut() != 1 ||
+			ft.In(0) != ft.Out(0) {
+			return fmt.Errorf("expects: func (K) K not %s (@%d)", ft, n)
+		}
+
+		i.Lock()
+		prop := i.props[ft.In(0)]
+		prop.mapKeyNormalizer = vfn
+		i.props[ft.In(0)] = prop
+		i.Unlock()
+	}
+	return nil
+}
+
+// MapKeyNormalizer returns the normalizer of map keys of type t, or
+// an invalid [reflect.Value] if none has been recorded.
+func (i *Info) MapKeyNormalizer(t reflect.Type) reflect.Value {
+	if i == nil {
+		return reflect.Value{}
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	return i.props[t].mapKeyNormalizer
+}
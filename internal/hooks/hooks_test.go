@@ -8,6 +8,7 @@ package hooks_test
 
 import (
 	"errors"
+	"fmt"
 	"net"
 	"reflect"
 	"strconv"
@@ -51,7 +52,7 @@ func TestAddCmpHooks(t *testing.T) {
 			err:  "expects: func (T, T) bool|error not func(int, bool) bool (@1)",
 		},
 		{
-			name: "interface",
+			name: "empty interface",
 			cmp:  func(a, b any) bool { return true },
 			err:  "expects: func (T, T) bool|error not func(interface {}, interface {}) bool (@1)",
 		},
@@ -136,6 +137,51 @@ func TestCmp(t *testing.T) {
 		}
 		test.IsTrue(t, handled)
 	})
+
+	t.Run("interface", func(t *testing.T) {
+		i := hooks.NewInfo()
+
+		err := i.AddCmpHooks([]any{
+			func(a, b fmt.Stringer) bool { return a.String() == b.String() },
+			func(a, b error) bool { return false }, // never reached for *net.AddrError
+		})
+		test.NoError(t, err)
+
+		ae1 := &net.AddrError{Err: "err", Addr: "1"}
+		ae2 := &net.AddrError{Err: "err", Addr: "2"}
+
+		// *net.AddrError implements error but not fmt.Stringer
+		handled, err := i.Cmp(reflect.ValueOf(ae1), reflect.ValueOf(ae1))
+		test.IsTrue(t, handled)
+		if err != hooks.ErrBoolean {
+			test.EqualErrorMessage(t, err, hooks.ErrBoolean)
+		}
+
+		d1, d2 := time.Second, time.Duration(1e9)
+		handled, err = i.Cmp(reflect.ValueOf(d1), reflect.ValueOf(d2))
+		test.NoError(t, err)
+		test.IsTrue(t, handled)
+
+		handled, err = i.Cmp(reflect.ValueOf(d1), reflect.ValueOf(ae2))
+		test.NoError(t, err)
+		test.IsFalse(t, handled)
+
+		// Concrete type takes precedence
+		err = i.AddCmpHooks([]any{func(a, b time.Duration) bool { return false }})
+		test.NoError(t, err)
+		handled, err = i.Cmp(reflect.ValueOf(d1), reflect.ValueOf(d2))
+		test.IsTrue(t, handled)
+		if err != hooks.ErrBoolean {
+			test.EqualErrorMessage(t, err, hooks.ErrBoolean)
+		}
+
+		// Recording again the same interface replaces the hook
+		err = i.AddCmpHooks([]any{func(a, b error) bool { return true }})
+		test.NoError(t, err)
+		handled, err = i.Cmp(reflect.ValueOf(ae1), reflect.ValueOf(ae2))
+		test.NoError(t, err)
+		test.IsTrue(t, handled)
+	})
 }
 
 func TestSmuggle(t *testing.T) {
@@ -180,6 +226,15 @@ func TestSmuggle(t *testing.T) {
 	handled, err = i.Smuggle(&got)
 	test.Error(t, err)
 	test.IsTrue(t, handled)
+	// Interface
+	err = i.AddSmuggleHooks([]any{func(a fmt.Stringer) string { return a.String() }})
+	test.NoError(t, err)
+
+	got = reflect.ValueOf(time.Second)
+	handled, err = i.Smuggle(&got)
+	test.NoError(t, err)
+	test.IsTrue(t, handled)
+	test.EqualStr(t, got.String(), "1s")
 }
 
 func TestAddSmuggleHooks(t *testing.T) {
@@ -204,7 +259,7 @@ func TestAddSmuggleHooks(t *testing.T) {
 			err:     "expects: func (A) (B[, error]) not func(int, int) bool (@1)",
 		},
 		{
-			name:    "interface",
+			name:    "empty interface",
 			smuggle: func(a any) bool { return true },
 			err:     "expects: func (A) (B[, error]) not func(interface {}) bool (@1)",
 		},
@@ -330,6 +385,63 @@ func TestAddIgnoreUnexported(t *testing.T) {
 	}
 }
 
+func TestEquateEmpty(t *testing.T) {
+	var i *hooks.Info
+
+	test.IsFalse(t, i.EquateEmpty(reflect.TypeOf([]int{})))
+
+	i = hooks.NewInfo()
+	test.IsFalse(t, i.EquateEmpty(reflect.TypeOf([]int{})))
+
+	test.NoError(t, i.AddEquateEmpty([]any{}))
+
+	test.NoError(t, i.AddEquateEmpty([]any{
+		[]int{}, map[string]bool(nil), reflect.TypeOf((*time.Time)(nil)),
+	}))
+	test.IsTrue(t, i.EquateEmpty(reflect.TypeOf([]int{})))
+	test.IsTrue(t, i.EquateEmpty(reflect.TypeOf(map[string]bool{})))
+	test.IsTrue(t, i.EquateEmpty(reflect.TypeOf(&time.Time{})))
+	test.IsFalse(t, i.EquateEmpty(reflect.TypeOf([]string{})))
+}
+
+func TestAddEquateEmpty(t *testing.T) {
+	i := hooks.NewInfo()
+
+	err := i.AddEquateEmpty([]any{[]int{}, 0})
+	if test.Error(t, err) {
+		test.EqualStr(t, err.Error(), "expects type int be a slice, a map or a pointer, not a int (@1)")
+	}
+
+	err = i.AddEquateEmpty([]any{nil})
+	if test.Error(t, err) {
+		test.EqualStr(t, err.Error(), "expects a slice, map or pointer type, not nil (@0)")
+	}
+
+	test.IsFalse(t, i.EquateEmpty(reflect.TypeOf([]int{})))
+}
+
+func TestIgnoreSliceOrder(t *testing.T) {
+	var i *hooks.Info
+
+	test.IsFalse(t, i.IgnoreSliceOrder(reflect.TypeOf(0)))
+
+	i = hooks.NewInfo()
+	test.IsFalse(t, i.IgnoreSliceOrder(reflect.TypeOf(0)))
+
+	test.NoError(t, i.AddIgnoreSliceOrder([]any{}))
+
+	test.NoError(t, i.AddIgnoreSliceOrder([]any{0, reflect.TypeOf("")}))
+	test.IsTrue(t, i.IgnoreSliceOrder(reflect.TypeOf(0)))
+	test.IsTrue(t, i.IgnoreSliceOrder(reflect.TypeOf("")))
+	test.IsFalse(t, i.IgnoreSliceOrder(reflect.TypeOf(0.0)))
+
+	err := i.AddIgnoreSliceOrder([]any{0.0, nil})
+	if test.Error(t, err) {
+		test.EqualStr(t, err.Error(), "expects a type, not nil (@1)")
+	}
+	test.IsFalse(t, i.IgnoreSliceOrder(reflect.TypeOf(0.0)))
+}
+
 func TestCopy(t *testing.T) {
 	var orig *hooks.Info
 
@@ -411,3 +523,118 @@ func (badEqualInAssign) Equal(a int) bool { return false }
 type badEqualOutType struct{}
 
 func (badEqualOutType) Equal(a badEqualOutType) int { return 42 }
+
+func TestConversions(t *testing.T) {
+	var i *hooks.Info
+	test.IsTrue(t, i.Conversion(reflect.TypeOf(0), reflect.TypeOf(""), 1) == nil)
+
+	i = hooks.NewInfo()
+	test.IsTrue(t, i.Conversion(reflect.TypeOf(0), reflect.TypeOf(""), 1) == nil)
+
+	type myInt int
+	err := i.AddConversions([]any{
+		strconv.Itoa,
+		func(s string) ([]byte, error) { return []byte(s), nil },
+		func(n myInt) int { return int(n) },
+	})
+	test.NoError(t, err)
+
+	chain := i.Conversion(reflect.TypeOf(0), reflect.TypeOf(""), 1)
+	test.EqualInt(t, len(chain), 1)
+
+	test.IsTrue(t, i.Conversion(reflect.TypeOf(""), reflect.TypeOf(0), 3) == nil)
+
+	// Transitive
+	test.IsTrue(t, i.Conversion(reflect.TypeOf(myInt(0)), reflect.TypeOf(""), 1) == nil)
+	chain = i.Conversion(reflect.TypeOf(myInt(0)), reflect.TypeOf(""), 2)
+	test.EqualInt(t, len(chain), 2)
+	test.IsTrue(t, i.Conversion(reflect.TypeOf(myInt(0)), reflect.TypeOf([]byte{}), 2) == nil)
+	chain = i.Conversion(reflect.TypeOf(myInt(0)), reflect.TypeOf([]byte{}), 3)
+	test.EqualInt(t, len(chain), 3)
+
+	// Replace + Copy
+	ni := i.Copy()
+	err = ni.AddConversions([]any{func(n int) string { return "replaced" }})
+	test.NoError(t, err)
+	chain = ni.Conversion(reflect.TypeOf(0), reflect.TypeOf(""), 1)
+	if test.EqualInt(t, len(chain), 1) {
+		test.EqualStr(t, chain[0].Call([]reflect.Value{reflect.ValueOf(1)})[0].String(), "replaced")
+	}
+	chain = i.Conversion(reflect.TypeOf(0), reflect.TypeOf(""), 1)
+	if test.EqualInt(t, len(chain), 1) {
+		test.EqualStr(t, chain[0].Call([]reflect.Value{reflect.ValueOf(1)})[0].String(), "1")
+	}
+
+	// Reset
+	i.Reset()
+	test.IsTrue(t, i.Conversion(reflect.TypeOf(0), reflect.TypeOf(""), 1) == nil)
+	test.EqualInt(t, len(ni.Conversion(reflect.TypeOf(0), reflect.TypeOf(""), 1)), 1)
+}
+
+func TestAddConversions(t *testing.T) {
+	for _, tst := range []struct {
+		name string
+		conv any
+		err  string
+	}{
+		{
+			name: "not a function",
+			conv: "zip",
+			err:  "expects a function, not a string (@1)",
+		},
+		{
+			name: "no variadic",
+			conv: func(a ...byte) bool { return true },
+			err:  "expects: func (A) (B[, error]) with A ≠ B not func(...uint8) bool (@1)",
+		},
+		{
+			name: "in",
+			conv: func(a, b int) bool { return true },
+			err:  "expects: func (A) (B[, error]) with A ≠ B not func(int, int) bool (@1)",
+		},
+		{
+			name: "same type",
+			conv: func(a int) int { return a },
+			err:  "expects: func (A) (B[, error]) with A ≠ B not func(int) int (@1)",
+		},
+		{
+			name: "bad return",
+			conv: func(a int) (int, int) { return 0, 0 },
+			err:  "expects: func (A) (B[, error]) with A ≠ B not func(int) (int, int) (@1)",
+		},
+	} {
+		i := hooks.NewInfo()
+
+		err := i.AddConversions([]any{
+			func(a int) bool { return true },
+			tst.conv,
+		})
+		if test.Error(t, err, tst.name) {
+			if !strings.Contains(err.Error(), tst.err) {
+				t.Errorf("<%s> does not contain <%s> for %s", err, tst.err, tst.name)
+			}
+		}
+	}
+}
+
+func TestMapKeyNormalizers(t *testing.T) {
+	var i *hooks.Info
+	test.IsFalse(t, i.MapKeyNormalizer(reflect.TypeOf("")).IsValid())
+
+	i = hooks.NewInfo()
+	test.IsFalse(t, i.MapKeyNormalizer(reflect.TypeOf("")).IsValid())
+
+	test.NoError(t, i.AddMapKeyNormalizers([]any{strings.ToLower}))
+	test.IsTrue(t, i.MapKeyNormalizer(reflect.TypeOf("")).IsValid())
+	test.IsFalse(t, i.MapKeyNormalizer(reflect.TypeOf(0)).IsValid())
+
+	for _, fn := range []any{
+		"zip",
+		strconv.Itoa,
+		func(s ...string) string { return "" },
+		func(a, b string) string { return "" },
+		func(s string) (string, error) { return "", nil },
+	} {
+		test.Error(t, i.AddMapKeyNormalizers([]any{fn}))
+	}
+}
@@ -15,6 +15,7 @@ import (
 // Location records a place in a source file.
 type Location struct {
 	File      string // File name
+	FullPath  string // Full path of File, as known at compile time
 	Func      string // Function name
 	Line      int    // Line number inside file
 	Inside    string // Inside is used when Location is inside something else
@@ -38,6 +39,7 @@ func New(callDepth int) (loc Location, ok bool) {
 		return
 	}
 
+	loc.FullPath = loc.File
 	if index := strings.LastIndexAny(loc.File, `/\`); index >= 0 {
 		loc.File = loc.File[index+1:]
 	}
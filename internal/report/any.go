@@ -0,0 +1,12 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+//go:build !go1.18
+// +build !go1.18
+
+package report
+
+type any = interface{}
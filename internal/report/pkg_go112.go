@@ -0,0 +1,25 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+//go:build go1.12
+// +build go1.12
+
+package report
+
+import (
+	"runtime/debug"
+	"strings"
+)
+
+// pkgPath returns the import path of the package under test, as
+// recorded in the test binary build information. It falls back on
+// the test binary name if this information is not available.
+func pkgPath() string {
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Path != "" {
+		return strings.TrimSuffix(bi.Path, ".test")
+	}
+	return binName()
+}
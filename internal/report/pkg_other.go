@@ -0,0 +1,17 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+//go:build !go1.12
+// +build !go1.12
+
+package report
+
+// pkgPath returns the name of the package under test, based on the
+// test binary name, as build information is not available before
+// go1.12.
+func pkgPath() string {
+	return binName()
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package report
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// htmlTemplate never closes body and html elements, as failures are
+// appended after the header all along the tests run. Browsers do not
+// care.
+const htmlTemplate = `{{define "header"}}<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>go-testdeep report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+h2 { border-bottom: 1px solid #ccc; }
+h3 { color: #b58900; font-size: 1em; }
+pre { background: #f6f8fa; padding: .5em; overflow-x: auto; tab-size: 4; }
+.error { margin: 0 0 1em 1em; }
+.title { color: #268bd2; font-family: monospace; font-weight: bold; }
+.got { color: #dc322f; }
+.expected { color: #2aa198; }
+.del { background: #fdd; color: #dc322f; }
+.ins { background: #dfd; color: #2aa198; }
+.hint { font-style: italic; }
+.location { color: #666; font-family: monospace; }
+.origin { border-left: 2px solid #ccc; padding-left: 1em; }
+</style>
+</head>
+<body>
+<h1>go-testdeep report</h1>
+{{end}}
+{{- define "test"}}<h2>{{.}}</h2>
+{{end}}
+{{- define "failure"}}<h3>{{.Title}}</h3>
+{{range .Errors}}{{template "error" .}}{{end}}{{if .Stack}}<details>
+<summary>This is how we got here</summary>
+<pre>{{.Stack}}</pre>
+</details>
+{{end}}{{end}}
+{{- define "error"}}<div class="error">
+<p class="title">{{if .Path}}{{.Path}}: {{end}}{{.Message}}</p>
+{{if .Summary}}<pre>{{.Summary}}</pre>
+{{else if or .Got .Expected}}<details open>
+<summary>got / expected</summary>
+<pre><span class="got">     got: {{.Got}}</span>
+<span class="expected">expected: {{.Expected}}</span></pre>
+</details>
+{{if .Diff}}<details open>
+<summary>diff</summary>
+<pre>{{range .Diff}}<span class="{{.Class}}">{{.Op}} {{.Text}}</span>
+{{end}}</pre>
+</details>
+{{end}}{{end}}{{if .Hint}}<p class="hint">hint: {{.Hint}}</p>
+{{end}}{{if .Origin}}<div class="origin">
+<p>Originates from following error:</p>
+{{template "error" .Origin}}</div>
+{{end}}{{if .Location}}<p class="location">[under operator {{.Location}}]</p>
+{{if .Source}}<pre>{{.Source}}</pre>
+{{end}}{{end}}</div>
+{{end}}`
+
+const markdownTemplate = `{{define "header"}}# go-testdeep report
+{{end}}
+{{- define "test"}}
+## {{.}}
+{{end}}
+{{- define "failure"}}
+### {{.Title}}
+{{range .Errors}}{{template "error" .}}{{end}}{{if .Stack}}
+<details><summary>This is how we got here</summary>
+
+{{fence .Stack}}
+</details>
+{{end}}{{end}}
+{{- define "error"}}
+**{{if .Path}}{{code .Path}}: {{end}}{{.Message}}**
+{{if .Summary}}
+{{fence .Summary}}
+{{else if or .Got .Expected}}
+<details open><summary>got / expected</summary>
+
+{{fence (print "     got: " .Got "\nexpected: " .Expected)}}
+</details>
+{{if .Diff}}
+{{fence (diff .Diff) "diff"}}
+{{end}}{{end}}{{if .Hint}}
+> hint: {{.Hint}}
+{{end}}{{if .Origin}}
+Originates from following error:
+{{template "error" .Origin}}{{end}}{{if .Location}}
+_[under operator {{.Location}}]_
+{{if .Source}}
+{{fence .Source "go"}}
+{{end}}{{end}}{{end}}`
+
+var (
+	htmlTmpl = htmltemplate.Must(htmltemplate.New("html").Parse(htmlTemplate))
+
+	markdownTmpl = template.Must(template.New("markdown").
+			Funcs(template.FuncMap{
+			"code":  mdCode,
+			"fence": mdFence,
+			"diff":  mdDiff,
+		}).
+		Parse(markdownTemplate))
+)
+
+// mdCode returns s as Markdown inline code.
+func mdCode(s string) string {
+	delim := "`"
+	for strings.Contains(s, delim) {
+		delim += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return delim + s + delim
+}
+
+// mdFence returns s as a Markdown fenced code block, optionally with
+// the lang info string.
+func mdFence(s string, lang ...string) string {
+	fence := "```"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	info := ""
+	if len(lang) > 0 {
+		info = lang[0]
+	}
+	return fence + info + "\n" + s + "\n" + fence
+}
+
+func mdDiff(lines []DiffLine) string {
+	var buf bytes.Buffer
+	for i, line := range lines {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line.Op)
+		buf.WriteString(line.Text)
+	}
+	return buf.String()
+}
+
+// render writes the part name of the report to w using r format.
+func (r *Report) render(w io.Writer, name string, data any) error {
+	if r.format == FormatMarkdown {
+		return markdownTmpl.ExecuteTemplate(w, name, data)
+	}
+	return htmlTmpl.ExecuteTemplate(w, name, data)
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+// Package report collects go-testdeep failures of a test binary run
+// in a self-contained HTML or Markdown file. See [EnvReport].
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/trace"
+)
+
+// EnvReport is the name of the environment variable containing the
+// path of the report file. When empty or unset, no report is
+// produced.
+//
+// If the path ends with ".md" or ".markdown", the report is written
+// in Markdown, else in HTML. As "go test ./..." runs one test binary
+// per package, possibly in parallel, the "{pkg}" placeholder is
+// replaced by the import path of the package under test, so each
+// binary has its own report. Slashes of the import path are kept, so
+// reports are organized in sub-directories, created as needed:
+//
+//	TESTDEEP_REPORT=/tmp/reports/{pkg}.html go test ./...
+//
+// As each test binary runs in its own package directory, a relative
+// path is relative to this directory, hence an absolute path is
+// generally wanted.
+const EnvReport = "TESTDEEP_REPORT"
+
+// Format is a report format.
+type Format uint8
+
+const (
+	// FormatHTML is a self-contained HTML page.
+	FormatHTML Format = iota
+	// FormatMarkdown is a Markdown document.
+	FormatMarkdown
+)
+
+// Report appends failures to a file as soon as they are added. It is
+// safe for concurrent use, so parallel tests can add their failures
+// simultaneously.
+type Report struct {
+	mu       sync.Mutex
+	path     string
+	format   Format
+	fh       *os.File
+	lastTest string
+}
+
+// Failure is a failed Cmp* call.
+type Failure struct {
+	Title  string
+	Errors []*Error
+	Stack  string
+}
+
+// Error is the report version of a [ctxerr.Error], colors stripped.
+type Error struct {
+	Path     string
+	Message  string
+	Got      string
+	Expected string
+	Summary  string
+	Hint     string
+	Location string
+	Source   string
+	Diff     []DiffLine
+	Origin   *Error
+}
+
+// DiffLine is a line of the diff between got and expected.
+type DiffLine struct {
+	Op   string // " " if line is common, "-" if only in got, "+" if only in expected
+	Text string
+}
+
+// Class returns the CSS class of the line.
+func (l DiffLine) Class() string {
+	switch l.Op {
+	case "-":
+		return "del"
+	case "+":
+		return "ins"
+	}
+	return "same"
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultReport *Report
+)
+
+// Default returns the report configured by [EnvReport] environment
+// variable, or nil if it is not set.
+func Default() *Report {
+	defaultOnce.Do(func() {
+		if path := os.Getenv(EnvReport); path != "" {
+			defaultReport = New(strings.Replace(path, "{pkg}", pkgPath(), -1))
+		}
+	})
+	return defaultReport
+}
+
+// binName returns the name of the package under test, based on the
+// test binary name.
+func binName() string {
+	name := filepath.Base(os.Args[0])
+	name = strings.TrimSuffix(name, ".exe")
+	return strings.TrimSuffix(name, ".test")
+}
+
+// New returns a new [*Report] written to path. The format depends on
+// path extension, see [EnvReport].
+func New(path string) *Report {
+	r := Report{path: path}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		r.format = FormatMarkdown
+	}
+	return &r
+}
+
+// Add appends a failure of test testName to r file, creating it
+// with the report header at the first call. Consecutive failures of
+// the same test are grouped under the same test heading. title is
+// the failure title, as displayed before err in test logs, and stack
+// the trace leading to the failure, if relevant. Add is a no-op if r
+// is nil.
+func (r *Report) Add(testName, title string, err *ctxerr.Error, stack trace.Stack) error {
+	if r == nil {
+		return nil
+	}
+
+	failure := Failure{
+		Title:  title,
+		Errors: newErrors(err),
+	}
+	if stack.IsRelevant() {
+		var buf bytes.Buffer
+		stack.Dump(&buf)
+		failure.Stack = buf.String()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var buf bytes.Buffer
+	if r.fh == nil {
+		if err := r.render(&buf, "header", nil); err != nil {
+			return err
+		}
+	}
+	if r.fh == nil || testName != r.lastTest {
+		if err := r.render(&buf, "test", testName); err != nil {
+			return err
+		}
+	}
+	if err := r.render(&buf, "failure", &failure); err != nil {
+		return err
+	}
+
+	if r.fh == nil {
+		if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+			return err
+		}
+		fh, err := os.Create(r.path)
+		if err != nil {
+			return err
+		}
+		r.fh = fh
+	}
+	r.lastTest = testName
+
+	// The file is never closed, the process exit does it. Each
+	// failure being written in one call, the report is always
+	// readable.
+	_, werr := r.fh.Write(buf.Bytes())
+	return werr
+}
+
+var ansiRe = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+func stripColors(s string) string {
+	return ansiRe.ReplaceAllLiteralString(s, "")
+}
+
+// newErrors converts err and all its following errors.
+func newErrors(err *ctxerr.Error) []*Error {
+	var errors []*Error
+	for ; err != nil; err = err.Next {
+		if err != ctxerr.BooleanError {
+			errors = append(errors, newError(err))
+		}
+	}
+	return errors
+}
+
+func newError(err *ctxerr.Error) *Error {
+	if err == ctxerr.ErrTooManyErrors {
+		return &Error{Message: err.Message}
+	}
+
+	e := Error{
+		Path:     err.Context.Path.StringStyle(err.Context.PathStyle),
+		Message:  err.Message,
+		Got:      stripColors(err.GotString()),
+		Expected: stripColors(err.ExpectedString()),
+		Summary:  stripColors(err.SummaryString()),
+		Hint:     err.Hint,
+	}
+	if pos := strings.Index(e.Message, "%%"); pos >= 0 {
+		e.Message = e.Message[:pos] + e.Path + e.Message[pos+2:]
+		e.Path = ""
+	}
+	if err.Summary == nil {
+		e.Diff = diff(e.Got, e.Expected)
+	}
+	if err.Location.IsInitialized() && !err.Location.BehindCmp {
+		e.Location = err.Location.String()
+		e.Source = stripColors(ctxerr.SourceString(err.Location, ctxerr.MaxSourceLines))
+	}
+	if err.Origin != nil {
+		e.Origin = newError(err.Origin)
+	}
+	return &e
+}
+
+// diffMaxCells is the maximum size of the LCS table computed by
+// diff. Beyond, no diff is produced.
+const diffMaxCells = 1 << 20
+
+// diff returns the line by line diff between got and expected, or
+// nil if they are both single lines or too big.
+func diff(got, expected string) []DiffLine {
+	a, b := strings.Split(got, "\n"), strings.Split(expected, "\n")
+	if (len(a) == 1 && len(b) == 1) || (len(a)+1)*(len(b)+1) > diffMaxCells {
+		return nil
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of
+	// a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	lines := make([]DiffLine, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, DiffLine{Op: " ", Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{Op: "-", Text: a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: "+", Text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{Op: "-", Text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{Op: "+", Text: b[j]})
+	}
+	return lines
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package report_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/location"
+	"github.com/maxatome/go-testdeep/internal/report"
+	"github.com/maxatome/go-testdeep/internal/test"
+	"github.com/maxatome/go-testdeep/internal/trace"
+)
+
+func newError() *ctxerr.Error {
+	return &ctxerr.Error{
+		Context:  ctxerr.Context{Path: ctxerr.NewPath("DATA").AddField("Name")},
+		Message:  "values differ",
+		Got:      "line1\nline2\nline3",
+		Expected: "line1\nLINE2\nline3",
+		Hint:     "<use uppercase>",
+		Location: location.Location{File: "foo_test.go", Func: "String", Line: 12},
+		Next: &ctxerr.Error{
+			Context: ctxerr.Context{Path: ctxerr.NewPath("DATA").AddField("Tags")},
+			Message: "comparing %% as a Bag",
+			Summary: ctxerr.NewSummary("Missing: 1 item"),
+			Origin: &ctxerr.Error{
+				Context:  ctxerr.Context{Path: ctxerr.NewPath("DATA")},
+				Message:  "type mismatch",
+				Got:      1,
+				Expected: 2,
+			},
+		},
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// nil report
+	var r *report.Report
+	test.NoError(t, r.Add("TestFoo", "Failed test", newError(), nil))
+
+	t.Run("Markdown", func(t *testing.T) {
+		path := filepath.Join(dir, "sub", "report.md")
+		r := report.New(path)
+		test.NoError(t, r.Add("TestFoo", "Failed test 'name'", newError(),
+			trace.Stack{
+				{Func: "TestFoo", FileLine: "/a/foo_test.go:12"},
+				{Func: "TestFoo.func1", FileLine: "/a/foo_test.go:14"},
+			}))
+		test.NoError(t, r.Add("TestBar", "Failed test", &ctxerr.Error{
+			Context:  ctxerr.Context{Path: ctxerr.NewPath("DATA")},
+			Message:  "values differ",
+			Got:      "`a`",
+			Expected: "b",
+		}, nil))
+
+		test.EqualStr(t, readFile(t, path), "# go-testdeep report\n"+`
+## TestFoo
+
+### Failed test 'name'
+
+**`+"`DATA.Name`"+`: values differ**
+
+<details open><summary>got / expected</summary>
+
+`+"```"+`
+     got: `+"`line1\nline2\nline3`"+`
+expected: `+"`line1\nLINE2\nline3`"+`
+`+"```"+`
+</details>
+
+`+"```diff"+`
+ `+"`line1"+`
+-line2
++LINE2
+ line3`+"`"+`
+`+"```"+`
+
+> hint: <use uppercase>
+
+_[under operator String at foo_test.go:12]_
+
+**comparing DATA.Tags as a Bag**
+
+`+"```"+`
+Missing: 1 item
+`+"```"+`
+
+Originates from following error:
+
+**`+"`DATA`"+`: type mismatch**
+
+<details open><summary>got / expected</summary>
+
+`+"```"+`
+     got: 1
+expected: 2
+`+"```"+`
+</details>
+
+<details><summary>This is how we got here</summary>
+
+`+"```"+`
+	TestFoo()       /a/foo_test.go:12
+	TestFoo.func1() /a/foo_test.go:14
+`+"```"+`
+</details>
+
+## TestBar
+
+### Failed test
+
+**`+"`DATA`"+`: values differ**
+
+<details open><summary>got / expected</summary>
+
+`+"```"+`
+     got: "`+"`a`"+`"
+expected: "b"
+`+"```"+`
+</details>
+`)
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		path := filepath.Join(dir, "report.html")
+		r := report.New(path)
+		err := newError()
+		err.Got = []string{"a", "b", "c"}
+		err.Expected = []string{"a", "B", "c"}
+		test.NoError(t, r.Add("TestFoo", "Failed test", err, nil))
+
+		html := readFile(t, path)
+		for _, expected := range []string{
+			"<h2>TestFoo</h2>",
+			`<p class="title">DATA.Name: values differ</p>`,
+			`<span class="same">  ([]string) (len=3 cap=3) {</span>`,
+			`<span class="del">-  (string) (len=1) &#34;b&#34;,</span>`,
+			`<span class="ins">&#43;  (string) (len=1) &#34;B&#34;,</span>`,
+			`<p class="hint">hint: &lt;use uppercase&gt;</p>`,
+			`<p class="title">comparing DATA.Tags as a Bag</p>`,
+			`<div class="origin">`,
+			`<p class="location">[under operator String at foo_test.go:12]</p>`,
+		} {
+			if !strings.Contains(html, expected) {
+				t.Errorf("%q not found in HTML report:\n%s", expected, html)
+			}
+		}
+	})
+
+	t.Run("Parallel", func(t *testing.T) {
+		path := filepath.Join(dir, "parallel.md")
+		r := report.New(path)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				test.NoError(t, r.Add(fmt.Sprintf("TestPar/%d", i%4), "Failed test",
+					newError(), nil))
+			}(i)
+		}
+		wg.Wait()
+
+		md := readFile(t, path)
+		test.EqualInt(t, strings.Count(md, "# go-testdeep report\n"), 1)
+		test.EqualInt(t, strings.Count(md, "\n### Failed test"), 20)
+
+		// Each failure is under the heading of its own test
+		failures := map[string]int{}
+		var curTest string
+		for _, line := range strings.Split(md, "\n") {
+			switch {
+			case strings.HasPrefix(line, "## "):
+				curTest = line[3:]
+			case line == "### Failed test":
+				failures[curTest]++
+			}
+		}
+		test.EqualInt(t, len(failures), 4)
+		for name, num := range failures {
+			if num != 5 {
+				t.Errorf("%s: %d failures found, but 5 expected", name, num)
+			}
+		}
+	})
+
+	t.Run("Write error", func(t *testing.T) {
+		r := report.New(filepath.Join(dir, "report.md", "sub", "x.md"))
+		test.NoError(t, ioutil.WriteFile(filepath.Join(dir, "report.md"), nil, 0644))
+		test.Error(t, r.Add("TestFoo", "Failed test", newError(), nil))
+	})
+}
@@ -130,7 +130,9 @@ func (t *TestingTB) Cleanup(fn func()) {
 		}
 		fn()
 	}
-	runtime.SetFinalizer(t, func(t *TestingTB) { t.cleanup() })
+	if old == nil {
+		runtime.SetFinalizer(t, func(t *TestingTB) { t.cleanup() })
+	}
 }
 
 // Fatal mocks [testing.T.Error] method.
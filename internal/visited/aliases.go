@@ -0,0 +1,110 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package visited
+
+import (
+	"reflect"
+)
+
+type aliasKey struct {
+	addr uintptr
+	typ  reflect.Type
+}
+
+type aliasEntry struct {
+	other aliasKey
+	path  string
+}
+
+type aliasLog struct {
+	got, expected aliasKey
+}
+
+// Aliases records the pairing of got and expected pointers (and maps)
+// during a comparison, so the pointer-sharing topology of got can be
+// checked against the expected one: if two expected pointers are the
+// same, the two corresponding got pointers have to be the same too,
+// and vice versa.
+type Aliases struct {
+	got      map[aliasKey]aliasEntry
+	expected map[aliasKey]aliasEntry
+	log      []aliasLog
+}
+
+// NewAliases returns a new [*Aliases] instance.
+func NewAliases() *Aliases {
+	return &Aliases{
+		got:      map[aliasKey]aliasEntry{},
+		expected: map[aliasKey]aliasEntry{},
+	}
+}
+
+func aliasKeyOf(v reflect.Value) (aliasKey, bool) {
+	switch v.Kind() {
+	case reflect.Map, reflect.Ptr:
+		if v.IsNil() {
+			return aliasKey{}, false
+		}
+		return aliasKey{addr: v.Pointer(), typ: v.Type()}, true
+	}
+	return aliasKey{}, false
+}
+
+// Check checks that got and expected pointers (or maps) are paired
+// consistently with the previously seen ones, and records them at
+// path. Non-nil pointers and maps are only concerned, for other
+// values Check always succeeds.
+//
+// If got has already been paired with another expected pointer,
+// Check returns false and the path where this pairing has been
+// recorded, and gotConflict is true. If expected has already been
+// paired with another got pointer, Check returns false and the path
+// where this pairing has been recorded, and gotConflict is false.
+// It is the caller responsibility to check that got and expected
+// types are the same.
+func (a *Aliases) Check(got, expected reflect.Value, path string) (ok bool, prevPath string, gotConflict bool) {
+	kg, okg := aliasKeyOf(got)
+	ke, oke := aliasKeyOf(expected)
+	if !okg || !oke {
+		return true, "", false
+	}
+
+	eg, gotSeen := a.got[kg]
+	if gotSeen && eg.other != ke {
+		return false, eg.path, true
+	}
+	ee, expectedSeen := a.expected[ke]
+	if expectedSeen && ee.other != kg {
+		return false, ee.path, false
+	}
+	if gotSeen { // && expectedSeen, already paired together
+		return true, "", false
+	}
+
+	a.got[kg] = aliasEntry{other: ke, path: path}
+	a.expected[ke] = aliasEntry{other: kg, path: path}
+	a.log = append(a.log, aliasLog{got: kg, expected: ke})
+	return true, "", false
+}
+
+// Mark returns the current state of a, to be passed to
+// [Aliases.Rollback].
+func (a *Aliases) Mark() int {
+	return len(a.log)
+}
+
+// Rollback forgets all pairings recorded since mark has been
+// returned by [Aliases.Mark]. It is typically used when a comparison
+// failed, as its pairings should not impact subsequent comparisons,
+// for example when an operator tries several candidates.
+func (a *Aliases) Rollback(mark int) {
+	for _, l := range a.log[mark:] {
+		delete(a.got, l.got)
+		delete(a.expected, l.expected)
+	}
+	a.log = a.log[:mark]
+}
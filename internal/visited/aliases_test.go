@@ -0,0 +1,62 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package visited_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/internal/test"
+	"github.com/maxatome/go-testdeep/internal/visited"
+)
+
+func TestAliases(t *testing.T) {
+	a := visited.NewAliases()
+
+	g1, g2, e1, e2 := new(int), new(int), new(int), new(int)
+	v := reflect.ValueOf
+
+	// Not pointers, nil pointers
+	ok, _, _ := a.Check(v(1), v(2), "DATA")
+	test.IsTrue(t, ok)
+	ok, _, _ = a.Check(v((*int)(nil)), v(e1), "DATA")
+	test.IsTrue(t, ok)
+
+	ok, _, _ = a.Check(v(g1), v(e1), "DATA[0]")
+	test.IsTrue(t, ok)
+	ok, _, _ = a.Check(v(g1), v(e1), "DATA[1]")
+	test.IsTrue(t, ok)
+
+	mark := a.Mark()
+
+	ok, path, gotConflict := a.Check(v(g1), v(e2), "DATA[2]")
+	test.IsFalse(t, ok)
+	test.EqualStr(t, path, "DATA[0]")
+	test.IsTrue(t, gotConflict)
+
+	ok, path, gotConflict = a.Check(v(g2), v(e1), "DATA[2]")
+	test.IsFalse(t, ok)
+	test.EqualStr(t, path, "DATA[0]")
+	test.IsFalse(t, gotConflict)
+
+	ok, _, _ = a.Check(v(g2), v(e2), "DATA[2]")
+	test.IsTrue(t, ok)
+
+	// Maps
+	gm, em := map[int]bool{}, map[int]bool{}
+	ok, _, _ = a.Check(v(gm), v(em), "DATA[3]")
+	test.IsTrue(t, ok)
+	ok, _, _ = a.Check(v(gm), v(map[int]bool{}), "DATA[4]")
+	test.IsFalse(t, ok)
+
+	// Pairings since mark are forgotten
+	a.Rollback(mark)
+	ok, _, _ = a.Check(v(g2), v(e1), "DATA[2]")
+	test.IsFalse(t, ok)
+	ok, _, _ = a.Check(v(gm), v(map[int]bool{}), "DATA[4]")
+	test.IsTrue(t, ok)
+}
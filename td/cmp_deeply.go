@@ -15,6 +15,7 @@ import (
 	"github.com/maxatome/go-testdeep/internal/color"
 	"github.com/maxatome/go-testdeep/internal/ctxerr"
 	"github.com/maxatome/go-testdeep/internal/flat"
+	"github.com/maxatome/go-testdeep/internal/report"
 	"github.com/maxatome/go-testdeep/internal/trace"
 )
 
@@ -119,26 +120,37 @@ func formatError(t TestingT, isFatal bool, err *ctxerr.Error, args ...any) {
 
 	args = flat.Interfaces(args...)
 
+	title := failedTest
+	if len(args) > 0 {
+		title += " '" + tdutil.BuildTestName(args...) + "'"
+	}
+
 	var buf bytes.Buffer
 	color.AppendTestNameOn(&buf)
-	if len(args) == 0 {
-		buf.WriteString(failedTest)
-	} else {
-		buf.WriteString(failedTest + " '")
-		tdutil.FbuildTestName(&buf, args...)
-		buf.WriteString("'")
-	}
+	buf.WriteString(title)
 	color.AppendTestNameOff(&buf)
 	buf.WriteString("\n")
 
 	err.Append(&buf, "")
 
 	// Stask trace
-	if s := stripTrace(trace.Retrieve(0, "testing.tRunner")); s.IsRelevant() {
+	s := stripTrace(trace.Retrieve(0, "testing.tRunner"))
+	if s.IsRelevant() {
 		buf.WriteString("\nThis is how we got here:\n")
 		s.Dump(&buf)
 	}
 
+	// Report enabled by TESTDEEP_REPORT env var
+	if r := report.Default(); r != nil {
+		var testName string
+		if tn, ok := t.(interface{ Name() string }); ok {
+			testName = tn.Name()
+		}
+		if rerr := r.Add(testName, title, err, s); rerr != nil {
+			buf.WriteString("\nCannot write " + report.EnvReport + " report: " + rerr.Error())
+		}
+	}
+
 	if isFatal {
 		t.Fatal(buf.String())
 	} else {
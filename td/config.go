@@ -10,6 +10,7 @@ import (
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/maxatome/go-testdeep/internal/anchors"
 	"github.com/maxatome/go-testdeep/internal/ctxerr"
@@ -41,6 +42,8 @@ type ContextConfig struct {
 	MaxErrors int
 	anchors   *anchors.Info
 	hooks     *hooks.Info
+	// scopedHooks contains the Cmp hooks added by (*T).WithCmpHooksAt
+	scopedHooks []ctxerr.ScopedHooks
 	// FailureIsFatal allows to Fatal() (instead of Error()) when a test
 	// fails. Using *testing.T or *testing.B instance as t.TB value, FailNow()
 	// is called behind the scenes when Fatal() is called. See testing
@@ -75,8 +78,176 @@ type ContextConfig struct {
 	// See (*T).IgnoreUnexported method to only apply this property to some
 	// specific types.
 	IgnoreUnexported bool
+	// EquateEmpty allows to consider nil and zero-length slices or
+	// maps as equal, at any depth of the comparison. It is typically
+	// useful after a JSON round-trip, where []T(nil) often becomes
+	// []T{} and vice versa.
+	//
+	// See (*T).EquateEmpty method to only apply this property to some
+	// specific types, and to consider a nil pointer equal to a pointer
+	// to a zero value.
+	EquateEmpty bool
+	// UseStructTags allows struct fields to declare how they have to
+	// be compared, using a testdeep struct tag containing a comma
+	// separated list of options:
+	//   - ignore: the field is ignored;
+	//   - lax: the field is compared as if BeLax was true;
+	//   - useEqual: the field is compared as if UseEqual was true;
+	//   - unordered: the slice field is compared as if the Bag
+	//     operator was used;
+	//   - approx=F: floats and complex numbers of the field are
+	//     compared with F absolute tolerance, see FloatTolerance;
+	//   - trunc=D: the time.Time field is truncated to D duration
+	//     before being compared, as TruncTime operator does;
+	//   - emptyEqNil: nil and empty slices or maps of the field are
+	//     equal as if EquateEmpty was true, as well as a nil pointer
+	//     and a pointer to a zero value if the field is a pointer.
+	//
+	// For example:
+	//
+	//   type Record struct {
+	//     ID        int64     `testdeep:"ignore"`
+	//     Score     float64   `testdeep:"approx=1e-6"`
+	//     Tags      []string  `testdeep:"unordered,emptyEqNil"`
+	//     CreatedAt time.Time `testdeep:"trunc=1s"`
+	//   }
+	//
+	// Struct tags are honoured during deep comparison as well as by
+	// Struct and SStruct operators. Options except ignore also apply
+	// when the field is compared against a TestDeep operator, but
+	// unordered, trunc and pointer emptyEqNil are then ineffective.
+	// A bad struct tag makes the comparison fail as soon as its struct
+	// is compared, whatever the field values are.
+	//
+	// See (*T).UseStructTags method.
+	UseStructTags bool
+	// IgnoreSliceOrder allows to compare all slices as if the Bag
+	// operator was used: the order of items is ignored, but each
+	// expected item has to match exactly one got item.
+	//
+	// See (*T).IgnoreSliceOrder method to only apply this property to
+	// some specific slices.
+	IgnoreSliceOrder bool
+	// IgnoreSliceOrderPaths contains glob-like patterns of paths of
+	// slices to compare as if the Bag operator was used. See
+	// IgnorePaths for the patterns syntax.
+	//
+	// See (*T).IgnoreSliceOrder method to add patterns.
+	IgnoreSliceOrderPaths []string
+	// SourceLines is the number of source lines (up to 3) to display
+	// around the location of the operator originator of a failure,
+	// with a caret under the operator call. 1 displays only the
+	// operator line, 2 adds the previous line and 3 adds the next one
+	// as well. Source files are read lazily and only once.
+	//
+	// It defaults to 0 (no source displayed) except if the environment
+	// variable TESTDEEP_SOURCE_LINES is set. In this latter case, the
+	// TESTDEEP_SOURCE_LINES value is converted to an int and used as is.
+	//
+	// Setting it to a negative number disables the display of source
+	// lines, even if TESTDEEP_SOURCE_LINES is set.
+	SourceLines int
+	// PathStyle is the style used to render the path of got data in
+	// failure reports. It defaults to PathStyleGo, rendering paths as
+	// DATA.Foo[3]["key"]*. PathStyleJSONPointer renders them as
+	// /foo/3/key and PathStyleJSONPath as $.foo[3].key. In these two
+	// latter styles, struct fields are named after their json struct
+	// tag, if any.
+	PathStyle PathStyle
+	// GroupErrors allows to group errors sharing the same message,
+	// the same operator location and the same path pattern, where
+	// array indexes are replaced by "*" (as in DATA[*].Status). Each
+	// group is then rendered once, with the number of errors in the
+	// group (and the number of items behind the pattern), the first
+	// indexes and the most frequent got and expected values.
+	//
+	// When set, MaxErrors applies to the number of groups and not to
+	// the number of errors: the comparison stops as soon as one group
+	// more than MaxErrors is encountered. As with ungrouped errors,
+	// MaxErrors 0 or 1 stops at the first error, so nothing is
+	// grouped.
+	GroupErrors bool
+	// IgnorePaths contains glob-like patterns of paths to skip
+	// entirely during the comparison, as if td.Ignore() was expected
+	// at these places. Patterns are matched against the path of got
+	// data, as displayed in failure reports using PathStyleGo, where
+	// "*" matches any single level and "**" zero or more levels:
+	//   - DATA.*.CreatedAt matches CreatedAt field (or key) of any
+	//     field (or key, or item) of the root;
+	//   - DATA.Items[*].ID matches ID field of any item of Items;
+	//   - **.UpdatedAt matches UpdatedAt field (or key) anywhere.
+	//
+	// Note that the first segment of each pattern matches RootName.
+	// Pointers are ignored, as well as function and custom levels,
+	// as the one added by Smuggle operator.
+	//
+	// See (*T).IgnorePaths method to add patterns.
+	IgnorePaths []string
+	// FloatTolerance is the tolerance used to compare float32,
+	// float64, complex64 and complex128 values during deep
+	// comparison, including nested ones. Its zero value means exact
+	// comparison. The real and imaginary parts of complex numbers are
+	// compared separately. NaN never equals anything. See
+	// FloatTolerance type and (*T).FloatTolerance method.
+	//
+	// Note that it only applies when expected is not a TestDeep
+	// operator: N or Between operators keep their own tolerance.
+	FloatTolerance FloatTolerance
+	// TimeTolerance is the maximum duration between got and expected
+	// time.Time values to consider them equal during deep comparison,
+	// including nested ones. When set, time.Time values are compared
+	// as instants, so time zones and monotonic clock readings are
+	// ignored. 0 means exact comparison. See (*T).TimeTolerance method.
+	TimeTolerance time.Duration
+	// CheckAliasing allows to check that got and expected share their
+	// pointers the same way. If two expected pointers (or maps) are
+	// the same, the two corresponding got ones have to be the same
+	// too, and vice versa. As a consequence, cycles have to close at
+	// the same relative positions in got and in expected.
+	//
+	// Without it, a got tree is equal to an expected graph with shared
+	// nodes as soon as their contents are equal, hiding aliasing bugs.
+	//
+	// Pairings recorded during a failed comparison are forgotten, so
+	// operators trying several candidates, as Bag or Any, only keep the
+	// pairings of the successful ones. See (*T).CheckAliasing method.
+	CheckAliasing bool
+	// MaxConversionHops is the maximum number of converters, recorded
+	// using (*T).WithConversions, chained to convert a got value to the
+	// expected type. 0 means 1: only direct converters are used.
+	MaxConversionHops int
 }
 
+// FloatTolerance defines the tolerances used to compare floats and
+// complex numbers. Two numbers are equal if at least one of the
+// non-zero tolerances is satisfied. See ContextConfig.FloatTolerance.
+type FloatTolerance struct {
+	// Absolute is the maximum absolute difference, as in |got-expected|.
+	Absolute float64
+	// Relative is the maximum difference relative to the largest
+	// magnitude, as in |got-expected| ≤ Relative×max(|got|, |expected|).
+	Relative float64
+	// ULPs is the maximum number of units in the last place, that is
+	// the number of representable floats between got and expected.
+	ULPs uint64
+}
+
+// PathStyle defines how the path of got data is rendered in failure
+// reports. See ContextConfig.PathStyle.
+type PathStyle uint8
+
+const (
+	// PathStyleGo renders paths as Go expressions, as in
+	// DATA.Foo[3]["key"]*. It is the default.
+	PathStyleGo = PathStyle(ctxerr.PathStyleGo)
+	// PathStyleJSONPointer renders paths as RFC 6901 JSON pointers, as
+	// in /foo/3/key.
+	PathStyleJSONPointer = PathStyle(ctxerr.PathStyleJSONPointer)
+	// PathStyleJSONPath renders paths as JSONPath expressions, as in
+	// $.foo[3].key.
+	PathStyleJSONPath = PathStyle(ctxerr.PathStyleJSONPath)
+)
+
 // Equal returns true if both c and o are equal. Only public fields
 // are taken into account to check equality.
 func (c ContextConfig) Equal(o ContextConfig) bool {
@@ -85,7 +256,31 @@ func (c ContextConfig) Equal(o ContextConfig) bool {
 		c.FailureIsFatal == o.FailureIsFatal &&
 		c.UseEqual == o.UseEqual &&
 		c.BeLax == o.BeLax &&
-		c.IgnoreUnexported == o.IgnoreUnexported
+		c.IgnoreUnexported == o.IgnoreUnexported &&
+		c.EquateEmpty == o.EquateEmpty &&
+		c.UseStructTags == o.UseStructTags &&
+		c.IgnoreSliceOrder == o.IgnoreSliceOrder &&
+		equalStrings(c.IgnoreSliceOrderPaths, o.IgnoreSliceOrderPaths) &&
+		c.SourceLines == o.SourceLines &&
+		c.PathStyle == o.PathStyle &&
+		c.GroupErrors == o.GroupErrors &&
+		equalStrings(c.IgnorePaths, o.IgnorePaths) &&
+		c.FloatTolerance == o.FloatTolerance &&
+		c.TimeTolerance == o.TimeTolerance &&
+		c.CheckAliasing == o.CheckAliasing &&
+		c.MaxConversionHops == o.MaxConversionHops
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
 }
 
 // OriginalPath returns the current path when the [ContextConfig] has
@@ -96,13 +291,14 @@ func (c ContextConfig) OriginalPath() string {
 	if c.forkedFromCtx == nil {
 		return c.RootName
 	}
-	return c.forkedFromCtx.Path.String()
+	return c.forkedFromCtx.Path.StringStyle(c.forkedFromCtx.PathStyle)
 }
 
 const (
 	contextDefaultRootName = "DATA"
 	contextPanicRootName   = "FUNCTION"
 	envMaxErrors           = "TESTDEEP_MAX_ERRORS"
+	envSourceLines         = "TESTDEEP_SOURCE_LINES"
 )
 
 func getMaxErrorsFromEnv() int {
@@ -116,6 +312,17 @@ func getMaxErrorsFromEnv() int {
 	return 10
 }
 
+func getSourceLinesFromEnv() int {
+	env := os.Getenv(envSourceLines)
+	if env != "" {
+		n, err := strconv.Atoi(env)
+		if err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
 // DefaultContextConfig is the default configuration used to render
 // tests failures. If overridden, new settings will impact all Cmp*
 // functions and [*T] methods (if not specifically configured.)
@@ -126,6 +333,8 @@ var DefaultContextConfig = ContextConfig{
 	UseEqual:         false,
 	BeLax:            false,
 	IgnoreUnexported: false,
+	EquateEmpty:      false,
+	SourceLines:      getSourceLinesFromEnv(),
 }
 
 func (c *ContextConfig) sanitize() {
@@ -135,6 +344,12 @@ func (c *ContextConfig) sanitize() {
 	if c.MaxErrors == 0 {
 		c.MaxErrors = DefaultContextConfig.MaxErrors
 	}
+	if c.SourceLines == 0 {
+		c.SourceLines = DefaultContextConfig.SourceLines
+	}
+	if c.hooks == nil {
+		c.hooks = registeredHooks
+	}
 }
 
 // newContext creates a new ctxerr.Context using DefaultContextConfig
@@ -153,16 +368,31 @@ func newContextWithConfig(tb testing.TB, config ContextConfig) (ctx ctxerr.Conte
 	config.sanitize()
 
 	ctx = ctxerr.Context{
-		Path:             ctxerr.NewPath(config.RootName),
-		Visited:          visited.NewVisited(),
-		MaxErrors:        config.MaxErrors,
-		Anchors:          config.anchors,
-		Hooks:            config.hooks,
-		OriginalTB:       tb,
-		FailureIsFatal:   config.FailureIsFatal,
-		UseEqual:         config.UseEqual,
-		BeLax:            config.BeLax,
-		IgnoreUnexported: config.IgnoreUnexported,
+		Path:                  ctxerr.NewPath(config.RootName),
+		Visited:               visited.NewVisited(),
+		MaxErrors:             config.MaxErrors,
+		Anchors:               config.anchors,
+		Hooks:                 config.hooks,
+		ScopedHooks:           config.scopedHooks,
+		OriginalTB:            tb,
+		FailureIsFatal:        config.FailureIsFatal,
+		UseEqual:              config.UseEqual,
+		BeLax:                 config.BeLax,
+		IgnoreUnexported:      config.IgnoreUnexported,
+		EquateEmpty:           config.EquateEmpty,
+		UseStructTags:         config.UseStructTags,
+		IgnoreSliceOrder:      config.IgnoreSliceOrder,
+		IgnoreSliceOrderPaths: ctxerr.ParsePathPatterns(config.IgnoreSliceOrderPaths),
+		SourceLines:           config.SourceLines,
+		PathStyle:             ctxerr.PathStyle(config.PathStyle),
+		GroupErrors:           config.GroupErrors,
+		IgnorePaths:           ctxerr.ParsePathPatterns(config.IgnorePaths),
+		FloatTolerance:        ctxerr.FloatTolerance(config.FloatTolerance),
+		TimeTolerance:         config.TimeTolerance,
+		MaxConversionHops:     config.MaxConversionHops,
+	}
+	if config.CheckAliasing {
+		ctx.Aliases = visited.NewAliases()
 	}
 
 	ctx.InitErrors()
@@ -171,11 +401,30 @@ func newContextWithConfig(tb testing.TB, config ContextConfig) (ctx ctxerr.Conte
 
 // newBooleanContext creates a new boolean ctxerr.Context.
 func newBooleanContext() ctxerr.Context {
-	return ctxerr.Context{
-		Visited:          visited.NewVisited(),
-		BooleanError:     true,
-		UseEqual:         DefaultContextConfig.UseEqual,
-		BeLax:            DefaultContextConfig.BeLax,
-		IgnoreUnexported: DefaultContextConfig.IgnoreUnexported,
+	rootName := DefaultContextConfig.RootName
+	if rootName == "" {
+		rootName = contextDefaultRootName
+	}
+
+	ctx := ctxerr.Context{
+		Path:                  ctxerr.NewPath(rootName),
+		Visited:               visited.NewVisited(),
+		BooleanError:          true,
+		Hooks:                 registeredHooks,
+		UseEqual:              DefaultContextConfig.UseEqual,
+		BeLax:                 DefaultContextConfig.BeLax,
+		IgnoreUnexported:      DefaultContextConfig.IgnoreUnexported,
+		EquateEmpty:           DefaultContextConfig.EquateEmpty,
+		UseStructTags:         DefaultContextConfig.UseStructTags,
+		IgnoreSliceOrder:      DefaultContextConfig.IgnoreSliceOrder,
+		IgnoreSliceOrderPaths: ctxerr.ParsePathPatterns(DefaultContextConfig.IgnoreSliceOrderPaths),
+		IgnorePaths:           ctxerr.ParsePathPatterns(DefaultContextConfig.IgnorePaths),
+		FloatTolerance:        ctxerr.FloatTolerance(DefaultContextConfig.FloatTolerance),
+		TimeTolerance:         DefaultContextConfig.TimeTolerance,
+		MaxConversionHops:     DefaultContextConfig.MaxConversionHops,
+	}
+	if DefaultContextConfig.CheckAliasing {
+		ctx.Aliases = visited.NewAliases()
 	}
+	return ctx
 }
@@ -37,7 +37,7 @@ func TestContext(t *testing.T) {
 	test.EqualStr(t, nctx.Path.String(), "DATA")
 
 	nctx = newBooleanContext()
-	test.EqualStr(t, nctx.Path.String(), "")
+	test.EqualStr(t, nctx.Path.String(), "DATA")
 	if nctx.OriginalTB != nil {
 		t.Error("OriginalTB should be nil")
 	}
@@ -83,3 +83,23 @@ func TestGetMaxErrorsFromEnv(t *testing.T) {
 	os.Setenv(envMaxErrors, "-8")
 	test.EqualInt(t, getMaxErrorsFromEnv(), -8)
 }
+
+func TestGetSourceLinesFromEnv(t *testing.T) {
+	oldEnv, set := os.LookupEnv(envSourceLines)
+	defer func() {
+		if set {
+			os.Setenv(envSourceLines, oldEnv)
+		} else {
+			os.Unsetenv(envSourceLines)
+		}
+	}()
+
+	os.Setenv(envSourceLines, "")
+	test.EqualInt(t, getSourceLinesFromEnv(), 0)
+
+	os.Setenv(envSourceLines, "aaa")
+	test.EqualInt(t, getSourceLinesFromEnv(), 0)
+
+	os.Setenv(envSourceLines, "3")
+	test.EqualInt(t, getSourceLinesFromEnv(), 3)
+}
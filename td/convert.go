@@ -0,0 +1,57 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package td
+
+import (
+	"reflect"
+
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/dark"
+)
+
+// maxConversionHops returns the maximum number of converters that
+// can be chained, 1 by default.
+func maxConversionHops(ctx ctxerr.Context) int {
+	if ctx.MaxConversionHops <= 0 {
+		return 1
+	}
+	return ctx.MaxConversionHops
+}
+
+// convertEqual converts got using the converters of chain, then
+// compares the result against expected.
+func convertEqual(ctx ctxerr.Context, got, expected reflect.Value, chain []reflect.Value) *ctxerr.Error {
+	label := "<convert " + got.Type().String()
+	for _, fn := range chain {
+		label += "→" + fn.Type().Out(0).String()
+	}
+	ctx = ctx.AddCustomLevel(label + ">")
+
+	// Values obtained from unexported fields cannot be passed to Call
+	if !got.CanInterface() {
+		got = reflect.ValueOf(dark.MustGetInterface(got))
+	}
+
+	for _, fn := range chain {
+		res := fn.Call([]reflect.Value{got})
+		if len(res) == 2 {
+			if err, _ := res[1].Interface().(error); err != nil {
+				if ctx.BooleanError {
+					return ctxerr.BooleanError
+				}
+				return ctx.CollectError(&ctxerr.Error{
+					Message: "conversion failed",
+					Got:     got,
+					Summary: ctxerr.NewSummary(err.Error()),
+				})
+			}
+		}
+		got = res[0]
+	}
+
+	return deepValueEqual(ctx, got, expected)
+}
@@ -280,6 +280,42 @@
 // See the [T.A] method (or its full name alias [T.Anchor])
 // documentation for details.
 //
+// # Registered hooks
+//
+// [RegisterCmpHooks], [RegisterSmuggleHooks], [RegisterUseEqual] and
+// [RegisterIgnoreUnexported] record hooks in a process-wide registry,
+// emptied by [ClearRegisteredHooks]. These functions are safe to call
+// concurrently, but are intended to be called from an init function
+// or a TestMain one, before any comparison.
+//
+// Registered hooks immediately apply to all Cmp* functions and to
+// all [*T] instances, existing ones included, as long as they did
+// not add hooks of their own. A [*T] instance on which
+// [T.WithCmpHooks], [T.WithSmuggleHooks], [T.WithConversions],
+// [T.MapKeyNormalizer], [T.UseEqual], [T.IgnoreUnexported],
+// [T.EquateEmpty] or [T.IgnoreSliceOrder] has been called takes a
+// snapshot of the registered hooks at that time: it never sees
+// hooks registered or cleared later.
+//
+// # Failures report
+//
+// Setting the TESTDEEP_REPORT environment variable to a file path
+// makes go-testdeep collect all the failures of the test binary in a
+// self-contained HTML file, or Markdown one if the path ends with
+// ".md" or ".markdown". Each failure comes under the heading of its
+// test, with its got and expected values, their line by line diff,
+// the hint and the operator location with its source lines. Failures
+// are appended to the file as soon as they occur, so it is always up
+// to date, even if parallel tests fail simultaneously.
+//
+// As "go test ./..." runs one test binary per package, the "{pkg}"
+// placeholder is replaced by the import path of the package under
+// test, creating sub-directories as needed. Each test binary running
+// in its own package directory, use an absolute path to get all the
+// reports in the same place:
+//
+//	TESTDEEP_REPORT=$PWD/reports/{pkg}.html go test ./...
+//
 // [go-testdeep]: https://go-testdeep.zetta.rocks/
 // [Test::Deep]: https://metacpan.org/pod/Test::Deep
 // ["operators"]: https://go-testdeep.zetta.rocks/operators/
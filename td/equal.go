@@ -131,6 +131,21 @@ func deepValueEqual(ctx ctxerr.Context, got, expected reflect.Value) (err *ctxer
 			"can only use it in expected one!"))
 	}
 
+	// Skip paths matching one of IgnorePaths patterns
+	if ctx.IsIgnoredPath() {
+		return
+	}
+
+	// Forget pointers pairings recorded during a failed comparison
+	if ctx.Aliases != nil {
+		mark := ctx.Aliases.Mark()
+		defer func() {
+			if err != nil {
+				ctx.Aliases.Rollback(mark)
+			}
+		}()
+	}
+
 	// Try to see if a TestDeep operator is anchored in expected
 	if op, ok := resolveAnchor(ctx, expected); ok {
 		expected = op
@@ -146,7 +161,9 @@ func deepValueEqual(ctx ctxerr.Context, got, expected reflect.Value) (err *ctxer
 	// Check if a Smuggle hook matches got type
 	if handled, e := ctx.Hooks.Smuggle(&got); handled {
 		if e != nil {
-			// ctx.BooleanError is always false here as hooks cannot be set globally
+			if ctx.BooleanError {
+				return ctxerr.BooleanError
+			}
 			return ctx.CollectError(&ctxerr.Error{
 				Message:  e.Error(),
 				Got:      got,
@@ -155,17 +172,29 @@ func deepValueEqual(ctx ctxerr.Context, got, expected reflect.Value) (err *ctxer
 		}
 	}
 
-	// Check if a Cmp hook matches got & expected types
-	if handled, e := ctx.Hooks.Cmp(got, expected); handled {
-		if e == nil {
-			return
+	// Check if a Cmp hook matches got & expected types. Skipped if
+	// expected is an operator, as it could match a hook recorded for
+	// an interface
+	if !expected.Type().Implements(testDeeper) {
+		if handled, e := ctx.CmpHooks(got, expected); handled {
+			if e == nil {
+				return
+			}
+			if ctx.BooleanError {
+				return ctxerr.BooleanError
+			}
+			return ctx.CollectError(&ctxerr.Error{
+				Message:  e.Error(),
+				Got:      got,
+				Expected: expected,
+			})
 		}
-		// ctx.BooleanError is always false here as hooks cannot be set globally
-		return ctx.CollectError(&ctxerr.Error{
-			Message:  e.Error(),
-			Got:      got,
-			Expected: expected,
-		})
+	}
+
+	// time.Time values compared with a tolerance
+	if ctx.TimeTolerance > 0 &&
+		got.Type() == types.Time && expected.Type() == types.Time {
+		return timeToleranceEqual(ctx, got, expected)
 	}
 
 	// Look for an Equal() method
@@ -216,6 +245,11 @@ func deepValueEqual(ctx ctxerr.Context, got, expected reflect.Value) (err *ctxer
 			})
 		}
 
+		// Look for a converter from got type to expected one
+		if chain := ctx.Hooks.Conversion(got.Type(), expected.Type(), maxConversionHops(ctx)); chain != nil {
+			return convertEqual(ctx, got, expected, chain)
+		}
+
 		if ctx.BeLax && types.IsConvertible(expected, got.Type()) {
 			return deepValueEqual(ctx, got, expected.Convert(got.Type()))
 		}
@@ -231,11 +265,20 @@ func deepValueEqual(ctx ctxerr.Context, got, expected reflect.Value) (err *ctxer
 		if ctx.BooleanError {
 			return ctxerr.BooleanError
 		}
-		return ctx.CollectError(ctxerr.TypeMismatch(got.Type(), expected.Type()))
+		err = ctxerr.TypeMismatch(got.Type(), expected.Type())
+		err.Hint = hint(got, expected)
+		return ctx.CollectError(err)
 	}
 
 	// if ctx.Depth > 10 { panic("deepValueEqual") } // for debugging
 
+	// Check got and expected pointers are shared the same way
+	if ctx.Aliases != nil {
+		if err = checkAliasing(ctx, got, expected); err != nil {
+			return
+		}
+	}
+
 	// Avoid looping forever on cyclic references
 	if ctx.Visited.Record(got, expected) {
 		return
@@ -254,6 +297,9 @@ func deepValueEqual(ctx ctxerr.Context, got, expected reflect.Value) (err *ctxer
 
 	case reflect.Slice:
 		if got.IsNil() != expected.IsNil() {
+			if equateEmpty(ctx, got, expected) {
+				return
+			}
 			if ctx.BooleanError {
 				return ctxerr.BooleanError
 			}
@@ -264,6 +310,11 @@ func deepValueEqual(ctx ctxerr.Context, got, expected reflect.Value) (err *ctxer
 			})
 		}
 
+		// Compare as a Bag if slice order has to be ignored
+		if got.Type() != tupleType && ignoreSliceOrder(ctx, got.Type()) {
+			return sliceBagEqual(ctx, got, expected)
+		}
+
 		var (
 			gotLen      = got.Len()
 			expectedLen = expected.Len()
@@ -334,26 +385,49 @@ func deepValueEqual(ctx ctxerr.Context, got, expected reflect.Value) (err *ctxer
 		if got.Pointer() == expected.Pointer() {
 			return
 		}
+		// nil pointer vs pointer to a zero value
+		if got.IsNil() != expected.IsNil() && ctx.Hooks.EquateEmpty(got.Type()) {
+			return deepValueEqual(ctx.AddPtr(1), elemOrZero(got), elemOrZero(expected))
+		}
 		return deepValueEqual(ctx.AddPtr(1), got.Elem(), expected.Elem())
 
 	case reflect.Struct:
 		sType := got.Type()
 		ignoreUnexported := ctx.IgnoreUnexported || ctx.Hooks.IgnoreUnexported(sType)
+		var tags *structTags
+		if ctx.UseStructTags {
+			tags = getStructTags(sType)
+			if tags.err != nil {
+				return tags.badTagError(ctx)
+			}
+		}
+		var errsBefore int
+		if ctx.Errors != nil {
+			errsBefore = len(*ctx.Errors)
+		}
 		for i, n := 0, got.NumField(); i < n; i++ {
 			field := sType.Field(i)
 			if ignoreUnexported && field.PkgPath != "" {
 				continue
 			}
-			err = deepValueEqual(ctx.AddField(field.Name),
-				got.Field(i), expected.Field(i))
+			var opts *fieldOptions
+			if tags != nil {
+				opts = tags.fields[i]
+			}
+			err = deepValueEqualField(ctx, field, opts, got.Field(i), expected.Field(i))
 			if err != nil {
+				addStructHint(ctx, err, errsBefore, got, expected)
 				return
 			}
 		}
+		addStructHint(ctx, nil, errsBefore, got, expected)
 		return
 
 	case reflect.Map:
 		if got.IsNil() != expected.IsNil() {
+			if equateEmpty(ctx, got, expected) {
+				return
+			}
 			if ctx.BooleanError {
 				return ctxerr.BooleanError
 			}
@@ -364,15 +438,23 @@ func deepValueEqual(ctx ctxerr.Context, got, expected reflect.Value) (err *ctxer
 			})
 		}
 
+		if got.Pointer() == expected.Pointer() {
+			return
+		}
+
+		// Normalize keys if a normalizer is recorded for their type
+		if got, err = normalizeMapKeys(ctx, got, "got"); err != nil {
+			return ctx.CollectError(err)
+		}
+		if expected, err = normalizeMapKeys(ctx, expected, "expected"); err != nil {
+			return ctx.CollectError(err)
+		}
+
 		// Shortcut in boolean context
 		if ctx.BooleanError && got.Len() != expected.Len() {
 			return ctxerr.BooleanError
 		}
 
-		if got.Pointer() == expected.Pointer() {
-			return
-		}
-
 		var notFoundKeys []reflect.Value
 		foundKeys := map[any]bool{}
 
@@ -442,6 +524,11 @@ func deepValueEqual(ctx ctxerr.Context, got, expected reflect.Value) (err *ctxer
 		})
 
 	default:
+		// Floats and complex numbers compared with a tolerance
+		if ctx.FloatTolerance != (ctxerr.FloatTolerance{}) && isFloatKind(got.Kind()) {
+			return floatToleranceEqual(ctx, got, expected)
+		}
+
 		// Normal equality suffices
 		if dark.MustGetInterface(got) == dark.MustGetInterface(expected) {
 			return
@@ -457,6 +544,42 @@ func deepValueEqual(ctx ctxerr.Context, got, expected reflect.Value) (err *ctxer
 	}
 }
 
+// equateEmpty returns true if got and expected slices or maps are
+// both empty and nil and empty values are considered equal for their
+// type.
+func equateEmpty(ctx ctxerr.Context, got, expected reflect.Value) bool {
+	return got.Len() == 0 && expected.Len() == 0 &&
+		(ctx.EquateEmpty || ctx.Hooks.EquateEmpty(got.Type()))
+}
+
+// ignoreSliceOrder returns true if slices of type typ at ctx path
+// have to be compared as a Bag.
+func ignoreSliceOrder(ctx ctxerr.Context, typ reflect.Type) bool {
+	return ctx.IgnoreSliceOrder ||
+		ctx.Hooks.IgnoreSliceOrder(typ.Elem()) ||
+		ctx.IsUnorderedPath()
+}
+
+// sliceBagEqual compares got and expected slices as if expected
+// was a Bag operator.
+func sliceBagEqual(ctx ctxerr.Context, got, expected reflect.Value) *ctxerr.Error {
+	items := make([]reflect.Value, expected.Len())
+	for i := range items {
+		items[i] = expected.Index(i)
+	}
+	set := tdSetBase{kind: allSet, expectedItems: items}
+	return set.matchItems(ctx, got, "Bag")
+}
+
+// elemOrZero returns the value pointed by ptr, or the zero value of
+// the pointed type if ptr is nil.
+func elemOrZero(ptr reflect.Value) reflect.Value {
+	if ptr.IsNil() {
+		return reflect.Zero(ptr.Type().Elem())
+	}
+	return ptr.Elem()
+}
+
 func deepValueEqualOK(got, expected reflect.Value) bool {
 	return deepValueEqualFinal(newBooleanContext(), got, expected) == nil
 }
@@ -491,3 +614,24 @@ func EqDeeplyError(got, expected any) error {
 	}
 	return err
 }
+
+// checkAliasing checks that got and expected pointers (or maps) are
+// paired consistently with the previously seen ones. got and expected
+// have the same type.
+func checkAliasing(ctx ctxerr.Context, got, expected reflect.Value) *ctxerr.Error {
+	ok, prevPath, gotConflict := ctx.Aliases.Check(got, expected, ctx.Path.StringStyle(ctx.PathStyle))
+	if ok {
+		return nil
+	}
+	if ctx.BooleanError {
+		return ctxerr.BooleanError
+	}
+	summary := "expected pointer already paired with another got one at " + prevPath
+	if gotConflict {
+		summary = "got pointer already paired with another expected one at " + prevPath
+	}
+	return ctx.CollectError(&ctxerr.Error{
+		Message: "pointer aliasing differs",
+		Summary: ctxerr.NewSummary(summary),
+	})
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package td
+
+import (
+	"reflect"
+
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/dark"
+	"github.com/maxatome/go-testdeep/internal/types"
+)
+
+// HintRule is a rule able to suggest a fix when a comparison
+// fails. See [HintRules].
+type HintRule struct {
+	// Name identifies the rule, so it can be easily found in
+	// [HintRules] to be removed or replaced.
+	Name string
+	// Hint returns the suggestion for got and expected values, or ""
+	// if the rule does not apply. got and expected can be of different
+	// types (on type mismatch) or of the same struct type (when a
+	// struct comparison fails). Note that they can come from
+	// unexported fields, so CanInterface should be checked before
+	// calling their Interface method.
+	Hint func(got, expected reflect.Value) string
+}
+
+// HintRules is the table of rules used to append a hint to failure
+// reports. Rules are tried in order, the first one returning a
+// non-empty hint wins.
+//
+// Hints are looked for when a type mismatch occurs, and when the
+// comparison of two structs of the same type fails. In this latter
+// case, only the innermost struct gets a hint.
+//
+// HintRules can be extended from user code, typically in an init
+// function as it is not protected against concurrent accesses:
+//
+//	func init() {
+//	  td.HintRules = append(td.HintRules, td.HintRule{
+//	    Name: "decimal",
+//	    Hint: func(got, expected reflect.Value) string {
+//	      if got.Type() == decimalType && expected.Kind() == reflect.String {
+//	        return "use Smuggle(decimal.RequireFromString, expected)"
+//	      }
+//	      return ""
+//	    },
+//	  })
+//	}
+//
+// Setting it to nil disables hints.
+var HintRules = []HintRule{
+	{Name: "lax", Hint: hintLax},
+	{Name: "ptr", Hint: hintPtr},
+	{Name: "bytes-string", Hint: hintBytesString},
+	{Name: "use-equal", Hint: hintUseEqual},
+	{Name: "ignore-unexported", Hint: hintIgnoreUnexported},
+}
+
+// hint returns the first hint of HintRules applying to got and
+// expected, or "" if none applies.
+func hint(got, expected reflect.Value) string {
+	for _, rule := range HintRules {
+		if rule.Hint != nil {
+			if h := rule.Hint(got, expected); h != "" {
+				return h
+			}
+		}
+	}
+	return ""
+}
+
+// addStructHint adds a hint to the first error raised during the
+// comparison of got and expected structs, if it does not have one
+// yet. errsBefore is the number of collected errors before the
+// comparison started.
+func addStructHint(ctx ctxerr.Context, err *ctxerr.Error, errsBefore int, got, expected reflect.Value) {
+	if ctx.BooleanError {
+		return
+	}
+	if ctx.Errors != nil {
+		if len(*ctx.Errors) <= errsBefore {
+			return
+		}
+		err = (*ctx.Errors)[errsBefore]
+	}
+	if err == nil || err == ctxerr.ErrTooManyErrors || err.Hint != "" {
+		return
+	}
+	err.Hint = hint(got, expected)
+}
+
+func isNumberKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return false
+}
+
+// hintLax handles numbers of different types (like int vs int64) and
+// types sharing the same kind and convertible one to the other.
+func hintLax(got, expected reflect.Value) string {
+	gt, et := got.Type(), expected.Type()
+	if gt == et || !et.ConvertibleTo(gt) {
+		return ""
+	}
+	if gt.Kind() != et.Kind() &&
+		!(isNumberKind(gt.Kind()) && isNumberKind(et.Kind())) {
+		return ""
+	}
+	return "use Lax(expected) operator, CmpLax function or BeLax config " +
+		"to compare " + gt.String() + " against convertible " + et.String()
+}
+
+// hintPtr handles pointer vs value mismatches.
+func hintPtr(got, expected reflect.Value) string {
+	gt, et := got.Type(), expected.Type()
+	switch {
+	case gt.Kind() == reflect.Ptr && gt.Elem() == et:
+		return "got is a pointer: use Ptr(expected) operator to check the pointed value"
+	case et.Kind() == reflect.Ptr && et.Elem() == gt:
+		return "expected is a pointer while got is not: dereference expected"
+	}
+	return ""
+}
+
+// hintBytesString handles []byte vs string mismatches.
+func hintBytesString(got, expected reflect.Value) string {
+	gt, et := got.Type(), expected.Type()
+	switch {
+	case gt.Kind() == reflect.Slice && gt.Elem().Kind() == reflect.Uint8 &&
+		et.Kind() == reflect.String:
+		return "use String(expected) operator, or Smuggle(func(b []byte) string { return string(b) }, expected)"
+	case gt.Kind() == reflect.String &&
+		et.Kind() == reflect.Slice && et.Elem().Kind() == reflect.Uint8:
+		return "use Smuggle([]byte(nil), expected) to convert got to []byte"
+	}
+	return ""
+}
+
+// hintUseEqual handles types with an Equal method returning true
+// while a field by field comparison fails, as [time.Time] with
+// different time zones.
+func hintUseEqual(got, expected reflect.Value) string {
+	gt := got.Type()
+	if gt != expected.Type() || gt.Kind() != reflect.Struct {
+		return ""
+	}
+
+	g, ok := dark.GetInterface(got, true)
+	if !ok {
+		return ""
+	}
+	e, ok := dark.GetInterface(expected, true)
+	if !ok {
+		return ""
+	}
+
+	if hasEqual, isEqual := isCustomEqual(reflect.ValueOf(g), reflect.ValueOf(e)); !hasEqual || !isEqual {
+		return ""
+	}
+
+	if gt == types.Time {
+		return "got and expected are the same instant but in different time zones " +
+			"or with different monotonic clock readings: use UseEqual config or " +
+			"t.UseEqual(time.Time{}) to compare them using Equal method"
+	}
+	return "got.Equal(expected) returns true: use UseEqual config or " +
+		"t.UseEqual(" + gt.String() + "{}) to compare them using Equal method"
+}
+
+// hintIgnoreUnexported handles structs only composed of unexported
+// fields, without any Equal method.
+func hintIgnoreUnexported(got, expected reflect.Value) string {
+	gt := got.Type()
+	if gt != expected.Type() || gt.Kind() != reflect.Struct || gt.NumField() == 0 {
+		return ""
+	}
+	if _, ok := gt.MethodByName("Equal"); ok {
+		return ""
+	}
+	for i, n := 0, gt.NumField(); i < n; i++ {
+		if gt.Field(i).PkgPath == "" {
+			return ""
+		}
+	}
+	return gt.String() + " has only unexported fields: use IgnoreUnexported " +
+		"config or t.IgnoreUnexported(" + gt.String() + "{}) to ignore them, " +
+		"or t.WithCmpHooks to compare them"
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package td_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/test"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+type onlyUnexported struct {
+	a int
+	b string
+}
+
+func TestHints(t *testing.T) {
+	getHint := func(got, expected any) string {
+		t.Helper()
+		err := td.EqDeeplyError(got, expected)
+		if err == nil {
+			t.Fatal("an error was expected")
+		}
+		return err.(*ctxerr.Error).Hint
+	}
+
+	checkHint := func(got, expected any, contain string) {
+		t.Helper()
+		h := getHint(got, expected)
+		if contain == "" {
+			test.EqualStr(t, h, "")
+		} else if !strings.Contains(h, contain) {
+			t.Errorf("hint %q should contain %q", h, contain)
+		}
+	}
+
+	num := 12
+
+	checkHint(12, int64(12), "use Lax(expected) operator")
+	checkHint(12.0, 12, "use Lax(expected) operator")
+	checkHint(&num, 12, "use Ptr(expected) operator")
+	checkHint(12, &num, "expected is a pointer while got is not")
+	checkHint([]byte("foo"), "foo", "use String(expected) operator")
+	checkHint("foo", []byte("foo"), "use Smuggle([]byte(nil), expected)")
+	checkHint("foo", 12, "")
+
+	type MyStruct struct {
+		Name string
+		When time.Time
+	}
+	when := time.Date(2022, time.March, 4, 5, 6, 7, 0, time.UTC)
+	checkHint(
+		MyStruct{Name: "Bob", When: when},
+		MyStruct{Name: "Bob", When: when.In(time.FixedZone("X", 3600))},
+		"same instant but in different time zones")
+	checkHint(
+		MyStruct{Name: "Bob", When: when},
+		MyStruct{Name: "Bob", When: when.Add(time.Second)},
+		"")
+	checkHint(MyStruct{Name: "Bob"}, MyStruct{Name: "Alice"}, "")
+
+	checkHint(onlyUnexported{a: 1}, onlyUnexported{a: 2},
+		"td_test.onlyUnexported has only unexported fields")
+
+	// Hint is rendered
+	err := td.EqDeeplyError(12, int64(12))
+	if !strings.Contains(err.Error(), "\n\t    hint: use Lax(expected)") {
+		t.Errorf("hint not rendered in:\n%s", err)
+	}
+
+	// Only the first error of a failing struct gets the hint
+	ttt := test.NewTestingTB(t.Name())
+	tt := td.NewT(ttt, td.ContextConfig{MaxErrors: -1})
+	tt.Cmp(
+		[]onlyUnexported{{a: 1, b: "x"}, {a: 2}},
+		[]onlyUnexported{{a: 3, b: "y"}, {a: 4}})
+	test.EqualInt(t, strings.Count(ttt.LastMessage(), "hint: "), 2)
+
+	// User rules
+	defer func(save []td.HintRule) { td.HintRules = save }(td.HintRules)
+
+	td.HintRules = append([]td.HintRule{{
+		Name: "custom",
+		Hint: func(got, expected reflect.Value) string {
+			if got.Kind() == reflect.String && expected.Kind() == reflect.Int {
+				return "custom hint"
+			}
+			return ""
+		},
+	}}, td.HintRules...)
+	checkHint("foo", 12, "custom hint")
+	checkHint(12, int64(12), "use Lax(expected) operator")
+
+	td.HintRules = nil
+	checkHint(12, int64(12), "")
+}
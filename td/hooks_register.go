@@ -0,0 +1,114 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package td
+
+import (
+	"github.com/maxatome/go-testdeep/internal/color"
+	"github.com/maxatome/go-testdeep/internal/hooks"
+)
+
+// registeredHooks contains the hooks recorded by Register* functions.
+// It is shared, not copied, by DefaultContextConfig and more
+// generally by any [ContextConfig] without hooks of its own, so
+// registrations are seen by existing [*T] instances too.
+var registeredHooks = hooks.NewInfo()
+
+// RegisterCmpHooks records process-wide Cmp hooks using functions
+// passed in fns, as if [T.WithCmpHooks] was called on each [*T]
+// instance. See [T.WithCmpHooks] for fns possible signatures.
+//
+// See the "Registered hooks" section of the package documentation
+// for when to call it and which [*T] instances see the hooks. For
+// example:
+//
+//	func init() {
+//	  td.RegisterCmpHooks(
+//	    func (got, expected decimal.Decimal) bool {
+//	      return got.Equal(expected)
+//	    },
+//	  )
+//	}
+//
+// Hooks added to a [*T] instance using [T.WithCmpHooks] take
+// precedence over registered ones for the same type.
+//
+// RegisterCmpHooks panics if an item of fns is not a function or if
+// its signature does not match the expected ones.
+//
+// See also [ClearRegisteredHooks].
+func RegisterCmpHooks(fns ...any) {
+	err := registeredHooks.AddCmpHooks(fns)
+	if err != nil {
+		panic(color.Bad("RegisterCmpHooks " + err.Error()))
+	}
+}
+
+// RegisterSmuggleHooks records process-wide Smuggle hooks using
+// functions passed in fns, as if [T.WithSmuggleHooks] was called on
+// each [*T] instance. See [T.WithSmuggleHooks] for fns possible
+// signatures, and the "Registered hooks" section of the package
+// documentation.
+//
+// RegisterSmuggleHooks panics if an item of fns is not a function or
+// if its signature does not match the expected ones.
+//
+// See also [ClearRegisteredHooks].
+func RegisterSmuggleHooks(fns ...any) {
+	err := registeredHooks.AddSmuggleHooks(fns)
+	if err != nil {
+		panic(color.Bad("RegisterSmuggleHooks " + err.Error()))
+	}
+}
+
+// RegisterUseEqual records process-wide types whose Equal method has
+// to be used to compare them, as if [T.UseEqual] was called on each
+// [*T] instance with types. types items can also be [reflect.Type]
+// instances. See the "Registered hooks"
+// section of the package documentation.
+//
+// RegisterUseEqual panics if an item of types does not implement a
+// valid Equal method.
+//
+// See also [ClearRegisteredHooks].
+func RegisterUseEqual(types ...any) {
+	err := registeredHooks.AddUseEqual(types)
+	if err != nil {
+		panic(color.Bad("RegisterUseEqual " + err.Error()))
+	}
+}
+
+// RegisterIgnoreUnexported records process-wide struct types whose
+// unexported fields have to be ignored, as if [T.IgnoreUnexported]
+// was called on each [*T] instance with types. types items can also
+// be [reflect.Type] instances. See the "Registered hooks" section of
+// the package documentation.
+//
+// RegisterIgnoreUnexported panics if an item of types is not a struct.
+//
+// See also [ClearRegisteredHooks].
+func RegisterIgnoreUnexported(types ...any) {
+	err := registeredHooks.AddIgnoreUnexported(types)
+	if err != nil {
+		panic(color.Bad("RegisterIgnoreUnexported " + err.Error()))
+	}
+}
+
+// ClearRegisteredHooks removes all the hooks and types recorded by
+// [RegisterCmpHooks], [RegisterSmuggleHooks], [RegisterUseEqual] and
+// [RegisterIgnoreUnexported]. See the "Registered hooks" section of
+// the package documentation.
+//
+// It is typically used in tests of the registration itself:
+//
+//	func TestRegistered(t *testing.T) {
+//	  td.RegisterCmpHooks(uuidEq)
+//	  defer td.ClearRegisteredHooks()
+//	  …
+//	}
+func ClearRegisteredHooks() {
+	registeredHooks.Reset()
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package td_test
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/internal/test"
+	"github.com/maxatome/go-testdeep/td"
+)
+
+type registeredID string
+
+type registeredPriv struct {
+	Num  int
+	priv int
+}
+
+type registeredEqual struct{ v, ignored int }
+
+func (r registeredEqual) Equal(o registeredEqual) bool { return r.v == o.v }
+
+func TestRegisterHooks(tt *testing.T) {
+	defer td.ClearRegisteredHooks()
+
+	ttt := test.NewTestingTB(tt.Name())
+
+	// Before registration
+	test.IsFalse(tt, td.Cmp(ttt, registeredID("ABC"), registeredID("abc")))
+	test.IsFalse(tt, td.Cmp(ttt, "12", 12))
+	test.IsFalse(tt, td.Cmp(ttt, registeredEqual{1, 2}, registeredEqual{1, 3}))
+	test.IsFalse(tt, td.Cmp(ttt, registeredPriv{1, 1}, registeredPriv{1, 2}))
+
+	var wg sync.WaitGroup
+	for _, fn := range []func(){
+		func() {
+			td.RegisterCmpHooks(func(got, expected registeredID) bool {
+				return strings.EqualFold(string(got), string(expected))
+			})
+		},
+		func() { td.RegisterSmuggleHooks(strconv.Atoi) },
+		func() { td.RegisterUseEqual(registeredEqual{}) },
+		func() { td.RegisterIgnoreUnexported(registeredPriv{}) },
+	} {
+		wg.Add(1)
+		go func(fn func()) {
+			defer wg.Done()
+			fn()
+		}(fn)
+	}
+	wg.Wait()
+
+	// Package-level functions
+	test.IsTrue(tt, td.Cmp(ttt, registeredID("ABC"), registeredID("abc")))
+	test.IsTrue(tt, td.Cmp(ttt, "12", 12))
+	test.IsTrue(tt, td.Cmp(ttt, registeredEqual{1, 2}, registeredEqual{1, 3}))
+	test.IsTrue(tt, td.Cmp(ttt, registeredPriv{1, 1}, registeredPriv{1, 2}))
+	test.IsTrue(tt, td.EqDeeply(registeredID("ABC"), registeredID("abc")))
+	test.IsFalse(tt, td.EqDeeply("zip", 12))
+
+	// New *T
+	t := td.NewT(ttt)
+	test.IsTrue(tt, t.Cmp(registeredID("ABC"), registeredID("abc")))
+
+	// New *T with a specific ContextConfig
+	t = td.NewT(ttt, td.ContextConfig{MaxErrors: 3})
+	test.IsTrue(tt, t.Cmp(registeredID("ABC"), registeredID("abc")))
+
+	// *T own hooks take precedence
+	tOwn := td.NewT(ttt).WithCmpHooks(func(got, expected registeredID) bool {
+		return got == expected
+	})
+	test.IsFalse(tt, tOwn.Cmp(registeredID("ABC"), registeredID("abc")))
+	test.IsTrue(tt, tOwn.Cmp("12", 12))
+
+	// Clear
+	td.ClearRegisteredHooks()
+	test.IsFalse(tt, td.Cmp(ttt, registeredID("ABC"), registeredID("abc")))
+	test.IsFalse(tt, td.Cmp(ttt, "12", 12))
+	test.IsFalse(tt, t.Cmp(registeredID("ABC"), registeredID("abc")))
+
+	// tOwn kept a copy of registered hooks
+	test.IsTrue(tt, tOwn.Cmp("12", 12))
+
+	// Bad usage
+	for _, tst := range []struct {
+		name  string
+		fn    func()
+		panic string
+	}{
+		{
+			name:  "RegisterCmpHooks",
+			fn:    func() { td.RegisterCmpHooks(42) },
+			panic: "RegisterCmpHooks expects a function, not a int (@0)",
+		},
+		{
+			name:  "RegisterSmuggleHooks",
+			fn:    func() { td.RegisterSmuggleHooks(42) },
+			panic: "RegisterSmuggleHooks expects a function, not a int (@0)",
+		},
+		{
+			name:  "RegisterUseEqual",
+			fn:    func() { td.RegisterUseEqual(42) },
+			panic: "RegisterUseEqual expects type int owns an Equal method (@0)",
+		},
+		{
+			name:  "RegisterIgnoreUnexported",
+			fn:    func() { td.RegisterIgnoreUnexported(42) },
+			panic: "RegisterIgnoreUnexported expects type int be a struct, not a int (@0)",
+		},
+	} {
+		test.CheckPanic(tt, tst.fn, tst.panic)
+	}
+}
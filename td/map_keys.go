@@ -0,0 +1,104 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package td
+
+import (
+	"net/textproto"
+	"reflect"
+	"strings"
+
+	"github.com/maxatome/go-testdeep/helpers/tdutil"
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/dark"
+	"github.com/maxatome/go-testdeep/internal/util"
+)
+
+// MapKeyFoldCase is a map keys normalizer to be used with
+// [T.MapKeyNormalizer], so string keys are compared case
+// insensitively.
+func MapKeyFoldCase(key string) string {
+	// ToUpper first, so "ſ" and "s" or "K" (Kelvin) and "k" are folded
+	return strings.ToLower(strings.ToUpper(key))
+}
+
+// MapKeyCanonicalHeader is a map keys normalizer to be used with
+// [T.MapKeyNormalizer], so string keys are compared once
+// canonicalized as HTTP headers are, see [net/http.CanonicalHeaderKey].
+func MapKeyCanonicalHeader(key string) string {
+	return textproto.CanonicalMIMEHeaderKey(key)
+}
+
+// normalizeMapKeys returns a copy of map m whose keys are normalized
+// using the normalizer recorded for m keys type, if any. Otherwise m
+// is returned as is. name is used to qualify m in the error returned
+// when two keys are normalized to the same one. This error is not
+// collected, it is the caller responsibility to do it.
+func normalizeMapKeys(ctx ctxerr.Context, m reflect.Value, name string) (reflect.Value, *ctxerr.Error) {
+	fn := ctx.Hooks.MapKeyNormalizer(m.Type().Key())
+	if !fn.IsValid() || m.IsNil() {
+		return m, nil
+	}
+
+	// Values obtained from unexported fields cannot be set in a map
+	if !m.CanInterface() {
+		m = reflect.ValueOf(dark.MustGetInterface(m))
+	}
+
+	nm := reflect.MakeMapWithSize(m.Type(), m.Len())
+	origKeys := make(map[any]reflect.Value, m.Len())
+	for _, key := range tdutil.MapSortedKeys(m) {
+		nkey := fn.Call([]reflect.Value{key})[0]
+		if err := checkNormalizedKey(ctx, origKeys, key, nkey, name); err != nil {
+			return m, err
+		}
+		nm.SetMapIndex(nkey, m.MapIndex(key))
+	}
+	return nm, nil
+}
+
+// normalizeMapEntries returns a copy of entries whose keys are
+// normalized using the normalizer recorded for keyType, if any.
+// Otherwise entries is returned as is. As for [normalizeMapKeys], the
+// returned error is not collected.
+func normalizeMapEntries(ctx ctxerr.Context, entries []mapEntryInfo, keyType reflect.Type) ([]mapEntryInfo, *ctxerr.Error) {
+	fn := ctx.Hooks.MapKeyNormalizer(keyType)
+	if !fn.IsValid() {
+		return entries, nil
+	}
+
+	nentries := make([]mapEntryInfo, len(entries))
+	origKeys := make(map[any]reflect.Value, len(entries))
+	for i, entry := range entries {
+		nkey := fn.Call([]reflect.Value{entry.key})[0]
+		if err := checkNormalizedKey(ctx, origKeys, entry.key, nkey, "expected"); err != nil {
+			return entries, err
+		}
+		nentries[i] = mapEntryInfo{key: nkey, expected: entry.expected}
+	}
+	return nentries, nil
+}
+
+// checkNormalizedKey records nkey as the normalized version of key
+// in origKeys and returns an error if another key has already been
+// normalized to nkey.
+func checkNormalizedKey(ctx ctxerr.Context, origKeys map[any]reflect.Value, key, nkey reflect.Value, name string) *ctxerr.Error {
+	inkey := dark.MustGetInterface(nkey)
+	prev, exists := origKeys[inkey]
+	if !exists {
+		origKeys[inkey] = key
+		return nil
+	}
+	if ctx.BooleanError {
+		return ctxerr.BooleanError
+	}
+	return &ctxerr.Error{
+		Message: "map keys collision once normalized",
+		Summary: ctxerr.NewSummary(name + " keys " + util.ToString(prev) +
+			" and " + util.ToString(key) + " are both normalized to " +
+			util.ToString(nkey)),
+	}
+}
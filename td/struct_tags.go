@@ -0,0 +1,206 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package td
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/dark"
+	"github.com/maxatome/go-testdeep/internal/types"
+)
+
+// structTagName is the name of the struct tag read when
+// ContextConfig.UseStructTags is true.
+const structTagName = "testdeep"
+
+// fieldOptions contains the options of a testdeep struct tag.
+type fieldOptions struct {
+	ignore     bool
+	lax        bool
+	useEqual   bool
+	unordered  bool
+	emptyEqNil bool
+	approx     float64
+	trunc      time.Duration
+}
+
+// parseFieldOptions parses tag, the content of a testdeep struct tag.
+func parseFieldOptions(tag string) (opts fieldOptions, err error) {
+	for _, opt := range strings.Split(tag, ",") {
+		opt = strings.TrimSpace(opt)
+
+		var value string
+		if eq := strings.IndexByte(opt, '='); eq >= 0 {
+			opt, value = opt[:eq], opt[eq+1:]
+			if opt != "approx" && opt != "trunc" {
+				return opts, fmt.Errorf("option %q does not accept a value", opt)
+			}
+		}
+
+		switch opt {
+		case "":
+		case "ignore":
+			opts.ignore = true
+		case "lax":
+			opts.lax = true
+		case "useEqual":
+			opts.useEqual = true
+		case "unordered":
+			opts.unordered = true
+		case "emptyEqNil":
+			opts.emptyEqNil = true
+		case "approx":
+			opts.approx, err = strconv.ParseFloat(value, 64)
+			if err != nil || opts.approx <= 0 {
+				return opts, fmt.Errorf("option approx expects a positive float, not %q", value)
+			}
+		case "trunc":
+			opts.trunc, err = time.ParseDuration(value)
+			if err != nil || opts.trunc <= 0 {
+				return opts, fmt.Errorf("option trunc expects a positive duration, not %q", value)
+			}
+		default:
+			return opts, fmt.Errorf("unknown option %q", opt)
+		}
+	}
+	return opts, nil
+}
+
+// structTags contains the parsed testdeep struct tags of a struct
+// type.
+type structTags struct {
+	fields   []*fieldOptions // indexed by field index, nil if no tag
+	err      error           // error of the first bad tag, if any
+	badField reflect.StructField
+}
+
+// structTagsCache caches the *structTags of each struct type already
+// compared with ContextConfig.UseStructTags enabled, so tags are
+// parsed only once.
+var structTagsCache sync.Map // reflect.Type → *structTags
+
+// getStructTags returns the parsed testdeep struct tags of sType,
+// parsing them the first time sType is seen.
+func getStructTags(sType reflect.Type) *structTags {
+	if st, ok := structTagsCache.Load(sType); ok {
+		return st.(*structTags)
+	}
+
+	st := structTags{fields: make([]*fieldOptions, sType.NumField())}
+	for i := range st.fields {
+		field := sType.Field(i)
+		tag, ok := field.Tag.Lookup(structTagName)
+		if !ok {
+			continue
+		}
+		opts, err := parseFieldOptions(tag)
+		if err != nil {
+			st.err = err
+			st.badField = field
+			break
+		}
+		st.fields[i] = &opts
+	}
+
+	cached, _ := structTagsCache.LoadOrStore(sType, &st)
+	return cached.(*structTags)
+}
+
+// getFieldTags returns the parsed testdeep struct tags of the struct
+// owning the field at index of sType, as used by
+// reflect.Type.FieldByIndex, and the index of this field in it.
+func getFieldTags(sType reflect.Type, index []int) (*structTags, int) {
+	last := len(index) - 1
+	for _, i := range index[:last] {
+		sType = sType.Field(i).Type
+		if sType.Kind() == reflect.Ptr {
+			sType = sType.Elem()
+		}
+	}
+	return getStructTags(sType), index[last]
+}
+
+// badTagError returns the error corresponding to the first bad tag
+// of st.
+func (st *structTags) badTagError(ctx ctxerr.Context) *ctxerr.Error {
+	if ctx.BooleanError {
+		return ctxerr.BooleanError
+	}
+	return ctx.AddStructField(st.badField).CollectError(&ctxerr.Error{
+		Message: "bad " + structTagName + " struct tag",
+		Summary: ctxerr.NewSummary(st.err.Error()),
+	})
+}
+
+// deepValueEqualField compares got and expected values of the struct
+// field field, honouring opts, the options of its testdeep struct
+// tag, if not nil.
+func deepValueEqualField(ctx ctxerr.Context, field reflect.StructField, opts *fieldOptions, got, expected reflect.Value) *ctxerr.Error {
+	ctx = ctx.AddStructField(field)
+
+	if opts == nil {
+		return deepValueEqual(ctx, got, expected)
+	}
+
+	if opts.ignore {
+		return nil
+	}
+	if opts.lax {
+		ctx.BeLax = true
+	}
+	if opts.useEqual {
+		ctx.UseEqual = true
+	}
+	if opts.emptyEqNil {
+		ctx.EquateEmpty = true
+	}
+	if opts.approx > 0 {
+		ctx.FloatTolerance = ctxerr.FloatTolerance{Absolute: opts.approx}
+	}
+
+	// Following options only apply when expected is not an operator
+	if got.IsValid() && expected.IsValid() && got.Type() == expected.Type() {
+		switch {
+		case opts.trunc > 0 && got.Type() == types.Time:
+			return truncTimeEqual(ctx, got, expected, opts.trunc)
+
+		case opts.unordered && got.Kind() == reflect.Slice &&
+			got.IsNil() == expected.IsNil():
+			return sliceBagEqual(ctx, got, expected)
+
+		case opts.emptyEqNil && got.Kind() == reflect.Ptr &&
+			got.IsNil() != expected.IsNil():
+			return deepValueEqual(ctx.AddPtr(1), elemOrZero(got), elemOrZero(expected))
+		}
+	}
+
+	return deepValueEqual(ctx, got, expected)
+}
+
+// truncTimeEqual compares got and expected time.Time values once
+// truncated to trunc.
+func truncTimeEqual(ctx ctxerr.Context, got, expected reflect.Value, trunc time.Duration) *ctxerr.Error {
+	g := dark.MustGetInterface(got).(time.Time)
+	e := dark.MustGetInterface(expected).(time.Time)
+	if g.Truncate(trunc).Equal(e.Truncate(trunc)) {
+		return nil
+	}
+	if ctx.BooleanError {
+		return ctxerr.BooleanError
+	}
+	return ctx.CollectError(&ctxerr.Error{
+		Message:  "values differ (truncated to " + trunc.String() + ")",
+		Got:      got,
+		Expected: expected,
+	})
+}
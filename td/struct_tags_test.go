@@ -0,0 +1,86 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package td
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/maxatome/go-testdeep/internal/test"
+)
+
+func TestParseFieldOptions(t *testing.T) {
+	opts, err := parseFieldOptions("ignore, lax,useEqual,unordered,emptyEqNil,approx=1e-6,trunc=1s,")
+	test.NoError(t, err)
+	test.IsTrue(t, opts == fieldOptions{
+		ignore:     true,
+		lax:        true,
+		useEqual:   true,
+		unordered:  true,
+		emptyEqNil: true,
+		approx:     1e-6,
+		trunc:      time.Second,
+	})
+
+	opts, err = parseFieldOptions("")
+	test.NoError(t, err)
+	test.IsTrue(t, opts == fieldOptions{})
+
+	for tag, expected := range map[string]string{
+		"foo":          `unknown option "foo"`,
+		"lax=1":        `option "lax" does not accept a value`,
+		"approx":       `option approx expects a positive float, not ""`,
+		"approx=-1":    `option approx expects a positive float, not "-1"`,
+		"trunc=1":      `option trunc expects a positive duration, not "1"`,
+		"ignore,trunc": `option trunc expects a positive duration, not ""`,
+	} {
+		_, err := parseFieldOptions(tag)
+		if test.Error(t, err, tag) {
+			test.EqualStr(t, err.Error(), expected, tag)
+		}
+	}
+}
+
+func TestGetStructTags(t *testing.T) {
+	type Inner struct {
+		A int `testdeep:"lax"`
+	}
+	type Outer struct {
+		Inner
+		B int
+		C int `testdeep:"ignore"`
+	}
+
+	st := getStructTags(reflect.TypeOf(Outer{}))
+	test.NoError(t, st.err)
+	if test.EqualInt(t, len(st.fields), 3) {
+		test.IsTrue(t, st.fields[0] == nil)
+		test.IsTrue(t, st.fields[1] == nil)
+		test.IsTrue(t, st.fields[2] != nil && st.fields[2].ignore)
+	}
+
+	// Parsed once
+	test.IsTrue(t, getStructTags(reflect.TypeOf(Outer{})) == st)
+
+	// Embedded field
+	inner, idx := getFieldTags(reflect.TypeOf(Outer{}), []int{0, 0})
+	test.IsTrue(t, inner == getStructTags(reflect.TypeOf(Inner{})))
+	test.EqualInt(t, idx, 0)
+	test.IsTrue(t, inner.fields[0] != nil && inner.fields[0].lax)
+
+	type Bad struct {
+		A int
+		B int `testdeep:"foo"`
+		C int `testdeep:"bar"`
+	}
+	st = getStructTags(reflect.TypeOf(Bad{}))
+	if test.Error(t, st.err) {
+		test.EqualStr(t, st.err.Error(), `unknown option "foo"`)
+	}
+	test.EqualStr(t, st.badField.Name, "B")
+}
@@ -8,6 +8,8 @@ package td
 
 import (
 	"github.com/maxatome/go-testdeep/internal/color"
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/hooks"
 )
 
 // WithCmpHooks returns a new [*T] instance with new Cmp hooks recorded
@@ -21,11 +23,15 @@ import (
 //
 // First arg is always got, and second is always expected.
 //
-// A cannot be an interface. This restriction can be removed in the
-// future, if really needed.
+// A can be an interface, but not the empty one. In this case, the
+// hook is called for each got value whose type implements A, unless
+// a hook has been recorded for this concrete type, which always takes
+// precedence. When several interfaces match, the first recorded one
+// wins.
 //
 // This function is called as soon as possible each time the type A is
-// encountered for got while expected type is assignable to A.
+// encountered for got while expected type is assignable to A. It is
+// never called when expected is an operator.
 //
 // When it returns a bool, false means A is not equal to B.
 //
@@ -57,6 +63,12 @@ import (
 //	  date, _ := time.Parse(time.RFC3339, "2020-09-08T22:13:54+02:00")
 //	  t.Cmp(date, date.UTC()) // succeeds
 //
+//	  // Compare all fmt.Stringer implementations using their String()
+//	  // method, except time.Time ones already handled above
+//	  t = t.WithCmpHooks(func (got, expected fmt.Stringer) bool {
+//	    return got.String() == expected.String()
+//	  })
+//
 //	  // Several hooks can be declared at once
 //	  t = t.WithCmpHooks(
 //	    func (got, expected reflect.Value) bool {
@@ -76,7 +88,7 @@ import (
 // WithCmpHooks calls t.Fatal if an item of fns is not a function or
 // if its signature does not match the expected ones.
 //
-// See also [T.WithSmuggleHooks].
+// See also [T.WithSmuggleHooks] and [T.WithCmpHooksAt].
 //
 // [UseEqual]: https://pkg.go.dev/github.com/maxatome/go-testdeep/td#ContextConfig.UseEqual
 func (t *T) WithCmpHooks(fns ...any) *T {
@@ -100,8 +112,10 @@ func (t *T) WithCmpHooks(fns ...any) *T {
 //	func (got A) B
 //	func (got A) (B, error)
 //
-// A cannot be an interface. This restriction can be removed in the
-// future, if really needed.
+// A can be an interface, but not the empty one. In this case, the
+// hook is called for each got value whose type implements A, unless
+// a hook has been recorded for this concrete type, which always takes
+// precedence.
 //
 // B cannot be an interface. If you have a use case, we can talk about it.
 //
@@ -159,6 +173,141 @@ func (t *T) WithSmuggleHooks(fns ...any) *T {
 	return t
 }
 
+// WithCmpHooksAt returns a new [*T] instance with new Cmp hooks
+// recorded using functions passed in fns, but only applying to paths
+// matching pattern. See [T.WithCmpHooks] for fns possible signatures
+// and [T.IgnorePaths] for pattern syntax.
+//
+//	t = t.WithCmpHooksAt("DATA.Meta.**", func (got, expected float64) bool {
+//	  return math.Abs(got-expected) < 0.01
+//	})
+//
+// Path-scoped hooks are tried before the global ones recorded using
+// [T.WithCmpHooks], the last recorded pattern first.
+//
+// WithCmpHooksAt calls t.Fatal if an item of fns is not a function or
+// if its signature does not match the expected ones.
+func (t *T) WithCmpHooksAt(pattern string, fns ...any) *T {
+	h := hooks.NewInfo()
+	err := h.AddCmpHooks(fns)
+	if err != nil {
+		t.Helper()
+		t.Fatal(color.Bad("WithCmpHooksAt " + err.Error()))
+	}
+
+	nt := NewT(t)
+	n := len(t.Config.scopedHooks)
+	nt.Config.scopedHooks = append(t.Config.scopedHooks[:n:n], ctxerr.ScopedHooks{
+		Pattern: ctxerr.ParsePathPattern(pattern),
+		Hooks:   h,
+	})
+	return nt
+}
+
+// WithConversions returns a new [*T] instance with new converters
+// recorded using functions passed in fns. Contrary to Smuggle hooks,
+// converters are keyed on the got and expected types pair.
+//
+// Each function in fns has to be a function with the following
+// possible signatures:
+//
+//	func (got A) B
+//	func (got A) (B, error)
+//
+// A and B have to be different types.
+//
+// When got type is A and expected type is B, got is converted to B
+// before being compared against expected. The conversion appears in
+// the path as in DATA.Field<convert A→B>. A non-nil error means the
+// conversion failed, its content is used to tell the reason of the
+// failure.
+//
+//	t = t.WithConversions(
+//	  func (got Status) string { return got.String() },
+//	  func (got sql.NullString) *string {
+//	    if got.Valid {
+//	      return &got.String
+//	    }
+//	    return nil
+//	  },
+//	)
+//	t.Cmp(StatusActive, "active") // succeeds
+//
+// Converters compose transitively: with converters A→C and C→B, got
+// A values can be compared against B ones. By default, only direct
+// converters are used, see [T.MaxConversionHops] to allow longer
+// chains.
+//
+// Converters are only used when expected is not an operator and
+// they are tried before [BeLax] feature.
+//
+// WithConversions calls t.Fatal if an item of fns is not a function
+// or if its signature does not match the expected ones.
+//
+// [BeLax]: https://pkg.go.dev/github.com/maxatome/go-testdeep/td#ContextConfig.BeLax
+func (t *T) WithConversions(fns ...any) *T {
+	t = t.copyWithHooks()
+
+	err := t.Config.hooks.AddConversions(fns)
+	if err != nil {
+		t.Helper()
+		t.Fatal(color.Bad("WithConversions " + err.Error()))
+	}
+
+	return t
+}
+
+// MaxConversionHops returns a new [*T] instance allowing to chain at
+// most n converters, recorded using [T.WithConversions], to convert
+// got to expected type. n ≤ 0 means 1, the default.
+func (t *T) MaxConversionHops(n int) *T {
+	new := *t
+	new.Config.MaxConversionHops = n
+	return &new
+}
+
+// MapKeyNormalizer returns a new [*T] instance with new map keys
+// normalizers recorded using functions passed in fns.
+//
+// Each function in fns has to be a function with the following
+// signature:
+//
+//	func (key K) K
+//
+// Then, each time maps with K keys are compared, during deep
+// comparison or using [Map], [SubMapOf] or [SuperMapOf] operators,
+// got and expected keys are normalized before being matched. If two
+// keys of the same map are normalized to the same value, an error is
+// raised.
+//
+// [MapKeyFoldCase] and [MapKeyCanonicalHeader] normalizers are
+// provided for string keys:
+//
+//	t.MapKeyNormalizer(td.MapKeyFoldCase).
+//	  Cmp(map[string]int{"Foo": 1}, map[string]int{"FOO": 1}) // succeeds
+//
+//	t.MapKeyNormalizer(td.MapKeyCanonicalHeader).
+//	  Cmp(http.Header{"content-type": {"text/plain"}},
+//	    http.Header{"Content-Type": {"text/plain"}}) // succeeds
+//
+// Note that as normalizers are keyed on K type, a normalizer for
+// string keys applies to all maps with string keys. Use a named
+// string type to restrict its scope.
+//
+// MapKeyNormalizer calls t.Fatal if an item of fns is not a function
+// or if its signature does not match the expected one.
+func (t *T) MapKeyNormalizer(fns ...any) *T {
+	t = t.copyWithHooks()
+
+	err := t.Config.hooks.AddMapKeyNormalizers(fns)
+	if err != nil {
+		t.Helper()
+		t.Fatal(color.Bad("MapKeyNormalizer " + err.Error()))
+	}
+
+	return t
+}
+
 func (t *T) copyWithHooks() *T {
 	nt := NewT(t)
 	nt.Config.hooks = t.Config.hooks.Copy()
@@ -7,6 +7,7 @@
 package td_test
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
@@ -19,6 +20,10 @@ import (
 	"github.com/maxatome/go-testdeep/td"
 )
 
+type stringerType struct{ s, ignored string }
+
+func (s stringerType) String() string { return s.s }
+
 func TestWithCmpHooks(tt *testing.T) {
 	na, nb := 1234, 1234
 	date, _ := time.Parse(time.RFC3339, "2020-09-08T22:13:54+02:00")
@@ -103,6 +108,71 @@ func TestWithCmpHooks(tt *testing.T) {
 		}
 	})
 
+	tt.Run("Interface", func(tt *testing.T) {
+		ttt := test.NewTestingTB(tt.Name())
+
+		t := td.NewT(ttt).
+			WithCmpHooks(func(got, expected fmt.Stringer) bool {
+				return got.String() == expected.String()
+			})
+
+		td.CmpTrue(tt, t.Cmp(stringerType{"a", "x"}, stringerType{"a", "y"}))
+		td.CmpFalse(tt, t.Cmp(stringerType{"a", "x"}, stringerType{"b", "x"}))
+
+		// Operators are not caught by interface hooks
+		td.CmpTrue(tt, t.Cmp(stringerType{"a", "x"}, td.Struct(stringerType{s: "a"}, nil)))
+
+		// Concrete type hooks take precedence
+		t = t.WithCmpHooks(func(got, expected stringerType) bool {
+			return got.ignored == expected.ignored
+		})
+		td.CmpTrue(tt, t.Cmp(stringerType{"a", "x"}, stringerType{"b", "x"}))
+		td.CmpFalse(tt, t.Cmp(stringerType{"a", "x"}, stringerType{"a", "y"}))
+	})
+
+	tt.Run("At", func(tt *testing.T) {
+		ttt := test.NewTestingTB(tt.Name())
+
+		type Meta struct{ Version, Size int }
+		type Data struct {
+			Size int
+			Meta Meta
+		}
+
+		t := td.NewT(ttt).
+			WithCmpHooksAt("DATA.Meta.**", func(got, expected int) bool {
+				return got/10 == expected/10
+			})
+
+		td.CmpTrue(tt, t.Cmp(
+			Data{Size: 12, Meta: Meta{Version: 21, Size: 34}},
+			Data{Size: 12, Meta: Meta{Version: 25, Size: 37}}))
+
+		td.CmpFalse(tt, t.Cmp(
+			Data{Size: 12, Meta: Meta{Version: 21, Size: 34}},
+			Data{Size: 13, Meta: Meta{Version: 21, Size: 34}}))
+		if !strings.Contains(ttt.LastMessage(), "DATA.Size: values differ\n") {
+			tt.Errorf(`<%s> does not contain "DATA.Size: values differ\n"`, ttt.LastMessage())
+		}
+
+		// Last recorded pattern first, then global hooks
+		t = t.WithCmpHooksAt("DATA.Meta.Size", func(got, expected int) error {
+			return errors.New("never equal")
+		}).WithCmpHooks(func(got, expected int) bool { return true })
+
+		td.CmpTrue(tt, t.Cmp(1, 2))
+		td.CmpFalse(tt, t.Cmp(Data{}, Data{}))
+		if !strings.Contains(ttt.LastMessage(), "DATA.Meta.Size: never equal\n") {
+			tt.Errorf(`<%s> does not contain "DATA.Meta.Size: never equal\n"`, ttt.LastMessage())
+		}
+
+		fatalMesg := ttt.CatchFatal(func() { t.WithCmpHooksAt("DATA", "Booh") })
+		test.IsTrue(tt, ttt.IsFatal)
+		if !strings.Contains(fatalMesg, "WithCmpHooksAt expects a function, not a string") {
+			tt.Errorf(`<%s> does not contain "WithCmpHooksAt expects a function, not a string"`, fatalMesg)
+		}
+	})
+
 	for _, tst := range []struct {
 		name  string
 		cmp   any
@@ -219,3 +289,123 @@ func TestWithSmuggleHooks(tt *testing.T) {
 		})
 	}
 }
+
+func TestWithConversions(tt *testing.T) {
+	type Status int
+	type Record struct {
+		Status Status
+		Name   sql.NullString
+	}
+	type RecordDTO struct {
+		Status string
+		Name   *string
+	}
+
+	name := "Bob"
+	got := Record{Status: 1, Name: sql.NullString{String: "Bob", Valid: true}}
+	expected := RecordDTO{Status: "active", Name: &name}
+
+	ttt := test.NewTestingTB(tt.Name())
+	t := td.NewT(ttt).
+		WithConversions(
+			func(got Status) (string, error) {
+				switch got {
+				case 0:
+					return "inactive", nil
+				case 1:
+					return "active", nil
+				}
+				return "", fmt.Errorf("unknown status %d", got)
+			},
+			func(got sql.NullString) *string {
+				if got.Valid {
+					return &got.String
+				}
+				return nil
+			},
+			func(got Record) RecordDTO {
+				return RecordDTO{Status: "?"}
+			})
+
+	td.CmpFalse(tt, td.Cmp(ttt, got.Status, "active"))
+	td.CmpTrue(tt, t.Cmp(got.Status, expected.Status))
+	td.CmpTrue(tt, t.Cmp(got.Name, expected.Name))
+
+	// Record→RecordDTO converter is not used with operators
+	td.CmpFalse(tt, t.Cmp(got, td.Struct(RecordDTO{}, nil)))
+	td.CmpTrue(tt, t.Cmp(got, RecordDTO{Status: "?"}))
+	td.CmpTrue(tt, t.Cmp([]any{Status(0), Status(1)}, []any{"inactive", "active"}))
+
+	td.CmpFalse(tt, t.Cmp([]any{Status(0), Status(1)}, []any{"inactive", "inactive"}))
+	td.CmpContains(tt, ttt.LastMessage(), `DATA[1]<convert td_test.Status→string>: values differ`)
+
+	td.CmpFalse(tt, t.Cmp([]any{Status(2)}, []any{"?"}))
+	td.CmpContains(tt, ttt.LastMessage(), `DATA[0]<convert td_test.Status→string>: conversion failed
+	unknown status 2`)
+
+	// Unexported fields
+	type private struct {
+		v any
+	}
+	td.CmpTrue(tt, t.Cmp(private{Status(1)}, private{"active"}))
+	td.CmpFalse(tt, t.Cmp(private{Status(1)}, private{"inactive"}))
+	td.CmpContains(tt, ttt.LastMessage(), `DATA.v<convert td_test.Status→string>: values differ`)
+
+	// Transitive conversions
+	type Code int
+	t = t.WithConversions(func(got Code) Status { return Status(got) })
+	td.CmpFalse(tt, t.Cmp(Code(1), "active"))
+	td.CmpTrue(tt, t.MaxConversionHops(2).Cmp(Code(1), "active"))
+	td.CmpFalse(tt, t.MaxConversionHops(2).Cmp(Code(1), "inactive"))
+	td.CmpContains(tt, ttt.LastMessage(), `DATA<convert td_test.Code→td_test.Status→string>: values differ`)
+
+	fatalMesg := ttt.CatchFatal(func() { t.WithConversions(func(a int) int { return a }) })
+	test.IsTrue(tt, ttt.IsFatal)
+	td.CmpContains(tt, fatalMesg, "WithConversions expects: func (A) (B[, error]) with A ≠ B not func(int) int (@0)")
+}
+
+func TestMapKeyNormalizer(tt *testing.T) {
+	ttt := test.NewTestingTB(tt.Name())
+	t := td.NewT(ttt)
+
+	got := map[string]int{"Foo": 1, "bar": 2}
+	expected := map[string]int{"FOO": 1, "Bar": 2}
+
+	td.CmpFalse(tt, t.Cmp(got, expected))
+
+	tf := t.MapKeyNormalizer(td.MapKeyFoldCase)
+	td.CmpTrue(tt, tf.Cmp(got, expected))
+	td.CmpTrue(tt, tf.Cmp(got, td.Map(map[string]int{"FOO": 1}, td.MapEntries{"BAR": 2})))
+	td.CmpTrue(tt, tf.Cmp(got, td.SubMapOf(map[string]int{"FOO": 1, "BAR": 2, "zip": 3}, nil)))
+	td.CmpTrue(tt, tf.Cmp(got, td.SuperMapOf(map[string]int{"BAR": 2}, nil)))
+	td.CmpTrue(tt, tf.Cmp(map[string]int{"ſ": 1}, map[string]int{"S": 1}))
+
+	td.CmpFalse(tt, tf.Cmp(got, map[string]int{"FOO": 1, "Bar": 3}))
+	td.CmpContains(tt, ttt.LastMessage(), `DATA["bar"]: values differ`)
+
+	// Collisions
+	td.CmpFalse(tt, tf.Cmp(map[string]int{"Foo": 1, "foo": 1}, expected))
+	td.CmpContains(tt, ttt.LastMessage(), `DATA: map keys collision once normalized
+	got keys "Foo" and "foo" are both normalized to "foo"`)
+
+	td.CmpFalse(tt, tf.Cmp(got, td.Map(map[string]int{"FOO": 1}, td.MapEntries{"foo": 1})))
+	td.CmpContains(tt, ttt.LastMessage(), `expected keys "foo" and "FOO" are both normalized to "foo"
+[under operator Map at`)
+
+	// HTTP headers
+	th := t.MapKeyNormalizer(td.MapKeyCanonicalHeader)
+	td.CmpTrue(tt, th.Cmp(
+		map[string][]string{"content-type": {"text/plain"}, "X-FOO": {"1"}},
+		map[string][]string{"Content-Type": {"text/plain"}, "x-foo": {"1"}}))
+
+	// Only concerns string keys
+	td.CmpFalse(tt, tf.Cmp(map[int]string{1: "a"}, map[int]string{1: "A"}))
+
+	// Unexported field
+	type private struct{ m map[string]int }
+	td.CmpTrue(tt, tf.Cmp(private{got}, private{expected}))
+
+	fatalMesg := ttt.CatchFatal(func() { t.MapKeyNormalizer(strings.ToLower, strconv.Itoa) })
+	test.IsTrue(tt, ttt.IsFatal)
+	td.CmpContains(tt, fatalMesg, "MapKeyNormalizer expects: func (K) K not func(int) string (@1)")
+}
@@ -11,6 +11,7 @@ import (
 	"reflect"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/maxatome/go-testdeep/helpers/tdutil"
 	"github.com/maxatome/go-testdeep/internal/color"
@@ -255,6 +256,125 @@ func (t *T) RootName(rootName string) *T {
 	return &new
 }
 
+// PathStyle changes the style used to render the path of got data in
+// failure reports. See [ContextConfig] PathStyle field for details.
+//
+// It returns a new instance of [*T] so does not alter the original t
+// and is used as follows:
+//
+//	t.PathStyle(td.PathStyleJSONPointer).Cmp(body, expectedBody)
+//
+// In case of error, the failure message will contain:
+//
+//	/items/3/id: values differ
+//
+// instead of:
+//
+//	DATA.Items[3].ID: values differ
+func (t *T) PathStyle(style PathStyle) *T {
+	new := *t
+	new.Config.PathStyle = style
+	return &new
+}
+
+// GroupErrors allows to group errors sharing the same message, the
+// same operator location and the same path pattern (where array
+// indexes are replaced by "*"). See [ContextConfig] GroupErrors field
+// for details.
+//
+// It returns a new instance of [*T] so does not alter the original t.
+//
+//	t.GroupErrors().Cmp(items, td.ArrayEach(td.SuperMapOf(map[string]any{
+//	  "Status": "done",
+//	}, nil)))
+//
+// can produce, instead of up to 10 near-identical errors:
+//
+//	DATA[*]["Status"]: values differ
+//	           items: 412 of 500
+//	         indexes: [0], [1], [2], [5], [7], …
+//	             got: "pending" (×400)
+//	                  "failed" (×12)
+//	        expected: "done" (×412)
+//	[under operator ArrayEach at items_test.go:12]
+//
+// Note that t.GroupErrors() acts as t.GroupErrors(true).
+func (t *T) GroupErrors(enable ...bool) *T {
+	new := *t
+	new.Config.GroupErrors = len(enable) == 0 || enable[0]
+	return &new
+}
+
+// IgnorePaths adds glob-like patterns of paths to skip entirely
+// during the comparison. See [ContextConfig] IgnorePaths field for
+// the patterns syntax.
+//
+// It returns a new instance of [*T] so does not alter the original t.
+//
+//	t = t.IgnorePaths("DATA.*.CreatedAt", "DATA.Items[*].ID", "**.UpdatedAt")
+//	t.Cmp(got, expectedFromFixtures)
+//
+// is useful when got and expected are plain Go values, where
+// td.Ignore() cannot be used.
+//
+// Calling t.IgnorePaths() without patterns returns an instance
+// without any pattern.
+func (t *T) IgnorePaths(patterns ...string) *T {
+	new := *t
+	if len(patterns) == 0 {
+		new.Config.IgnorePaths = nil
+	} else {
+		n := len(t.Config.IgnorePaths)
+		new.Config.IgnorePaths = append(t.Config.IgnorePaths[:n:n], patterns...)
+	}
+	return &new
+}
+
+// FloatTolerance sets the tolerance used to compare float32,
+// float64, complex64 and complex128 values reached during deep
+// comparison. Two numbers are equal if |got-expected| ≤ absolute, or
+// |got-expected| ≤ relative×max(|got|, |expected|), or if they are at
+// most ulps units in the last place apart. A zero tolerance is
+// ignored. See [ContextConfig] FloatTolerance field for details.
+//
+// It returns a new instance of [*T] so does not alter the original t.
+//
+//	t.FloatTolerance(1e-9, 1e-6, 0).Cmp(simulation.Run(), expectedResults)
+//
+// In case of error, the failure message contains the delta:
+//
+//	DATA.Results[12].Energy: values differ (delta 0.0042 out of tolerance)
+//
+// t.FloatTolerance(0, 0, 0) restores exact comparison.
+func (t *T) FloatTolerance(absolute, relative float64, ulps uint64) *T {
+	new := *t
+	new.Config.FloatTolerance = FloatTolerance{
+		Absolute: absolute,
+		Relative: relative,
+		ULPs:     ulps,
+	}
+	return &new
+}
+
+// TimeTolerance sets the maximum duration between got and expected
+// [time.Time] values reached during deep comparison to consider them
+// equal. See [ContextConfig] TimeTolerance field for details.
+//
+// It returns a new instance of [*T] so does not alter the original t.
+//
+//	t.TimeTolerance(time.Second).Cmp(record, expectedRecord)
+//
+// In case of error, the failure message contains the delta:
+//
+//	DATA.CreatedAt: values differ (delta 1.5s out of tolerance 1s)
+//
+// t.TimeTolerance(0) restores exact comparison.
+func (t *T) TimeTolerance(d time.Duration) *T {
+	new := *t
+	new.Config.TimeTolerance = d
+	return &new
+}
+
 // FailureIsFatal allows to choose whether t.TB.Fatal() or
 // t.TB.Error() will be used to print the next failure reports. When
 // enable is true (or missing) testing.Fatal() will be called, else
@@ -436,6 +556,180 @@ func (t *T) IgnoreUnexported(types ...any) *T {
 	return t
 }
 
+// EquateEmpty tells go-testdeep to consider nil and empty values of
+// types types as equal: nil and zero-length slices or maps, as well as
+// nil pointers and pointers to a zero value. It applies at any depth
+// of the comparison.
+//
+// It always returns a new instance of [*T] so does not alter the original t.
+//
+//	t = t.EquateEmpty([]string{}, map[string]int{}, (*Meta)(nil))
+//	t.Cmp([]string(nil), []string{})                  // succeeds
+//	t.Cmp(Record{Meta: nil}, Record{Meta: &Meta{}})   // succeeds
+//
+// types items can also be [reflect.Type] items. In this case, the
+// target type is the one reflected by the [reflect.Type].
+//
+//	t = t.EquateEmpty(reflect.TypeOf([]string{}))
+//
+// As a special case, calling t.EquateEmpty() or t.EquateEmpty(true)
+// returns an instance considering nil and zero-length slices and maps
+// equal, for all types. Pointers are never concerned by this global
+// mode, they have to be explicitly listed. t.EquateEmpty(false)
+// returns an instance not equating empty values anymore, except for
+// types already recorded using a previous EquateEmpty call.
+func (t *T) EquateEmpty(types ...any) *T {
+	// special case: EquateEmpty()
+	if len(types) == 0 {
+		new := *t
+		new.Config.EquateEmpty = true
+		return &new
+	}
+
+	// special cases: EquateEmpty(true) or EquateEmpty(false)
+	if len(types) == 1 {
+		if enable, ok := types[0].(bool); ok {
+			new := *t
+			new.Config.EquateEmpty = enable
+			return &new
+		}
+	}
+
+	// Enable EquateEmpty only for types types
+	t = t.copyWithHooks()
+
+	err := t.Config.hooks.AddEquateEmpty(types)
+	if err != nil {
+		t.Helper()
+		t.Fatal(color.Bad("EquateEmpty " + err.Error()))
+	}
+
+	return t
+}
+
+// UseStructTags allows to honour testdeep struct tags of compared
+// structs. See [ContextConfig] UseStructTags field for the supported
+// options.
+//
+// It returns a new instance of [*T] so does not alter the original t.
+//
+//	type Record struct {
+//	  ID        int64     `testdeep:"ignore"`
+//	  Tags      []string  `testdeep:"unordered"`
+//	  CreatedAt time.Time `testdeep:"trunc=1s"`
+//	}
+//
+//	t.UseStructTags().Cmp(got, Record{
+//	  Tags:      []string{"b", "a"},
+//	  CreatedAt: before,
+//	})
+//
+// Note that t.UseStructTags() acts as t.UseStructTags(true).
+func (t *T) UseStructTags(enable ...bool) *T {
+	new := *t
+	new.Config.UseStructTags = len(enable) == 0 || enable[0]
+	return &new
+}
+
+// IgnoreSliceOrder tells go-testdeep to compare some slices as if
+// the [Bag] operator was used: the order of items is ignored, but
+// each expected item has to match exactly one got item. It applies
+// at any depth of the comparison.
+//
+// It always returns a new instance of [*T] so does not alter the original t.
+//
+// typesOrPaths items can be:
+//   - a string, a glob-like pattern of the path of slices (see
+//     [ContextConfig] IgnorePaths field for the syntax);
+//   - a [reflect.Type] or any other value, whose type is the type
+//     of the items of slices.
+//
+// So:
+//
+//	t = t.IgnoreSliceOrder(Item{}, "DATA.Tags", "**.Roles")
+//	t.Cmp(got, expectedDTO)
+//
+// compares all []Item slices, as well as the slices at DATA.Tags
+// and at any Roles field, whatever the order of their items. To
+// target []string slices, use reflect.TypeOf("").
+//
+// As a special case, calling t.IgnoreSliceOrder() or
+// t.IgnoreSliceOrder(true) returns an instance ignoring the order of
+// all slices. t.IgnoreSliceOrder(false) returns an instance taking
+// the order into account again, except for types and paths already
+// recorded using a previous IgnoreSliceOrder call.
+func (t *T) IgnoreSliceOrder(typesOrPaths ...any) *T {
+	// special case: IgnoreSliceOrder()
+	if len(typesOrPaths) == 0 {
+		new := *t
+		new.Config.IgnoreSliceOrder = true
+		return &new
+	}
+
+	// special cases: IgnoreSliceOrder(true) or IgnoreSliceOrder(false)
+	if len(typesOrPaths) == 1 {
+		if enable, ok := typesOrPaths[0].(bool); ok {
+			new := *t
+			new.Config.IgnoreSliceOrder = enable
+			return &new
+		}
+	}
+
+	var (
+		paths []string
+		types []any
+	)
+	for _, item := range typesOrPaths {
+		if path, ok := item.(string); ok {
+			paths = append(paths, path)
+		} else {
+			types = append(types, item)
+		}
+	}
+
+	t = t.copyWithHooks()
+
+	if len(paths) > 0 {
+		n := len(t.Config.IgnoreSliceOrderPaths)
+		t.Config.IgnoreSliceOrderPaths = append(
+			t.Config.IgnoreSliceOrderPaths[:n:n], paths...)
+	}
+
+	// Enable IgnoreSliceOrder only for types types
+	err := t.Config.hooks.AddIgnoreSliceOrder(types)
+	if err != nil {
+		t.Helper()
+		t.Fatal(color.Bad("IgnoreSliceOrder " + err.Error()))
+	}
+
+	return t
+}
+
+// CheckAliasing allows to check that got and expected share their
+// pointers the same way: if two expected pointers (or maps) are the
+// same, the two corresponding got ones have to be the same too, and
+// vice versa. See [ContextConfig] CheckAliasing field for details.
+//
+// It returns a new instance of [*T] so does not alter the original t.
+//
+//	type Node struct {
+//	  Name string
+//	  Next *Node
+//	}
+//	shared := &Node{Name: "shared"}
+//	expected := [2]*Node{shared, shared}
+//
+//	got := [2]*Node{{Name: "shared"}, {Name: "shared"}}
+//	t.Cmp(got, expected)                 // succeeds
+//	t.CheckAliasing().Cmp(got, expected) // fails
+//
+// Note that t.CheckAliasing() acts as t.CheckAliasing(true).
+func (t *T) CheckAliasing(enable ...bool) *T {
+	new := *t
+	new.Config.CheckAliasing = len(enable) == 0 || enable[0]
+	return &new
+}
+
 // Cmp is mostly a shortcut for:
 //
 //	Cmp(t.TB, got, expected, args...)
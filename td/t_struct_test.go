@@ -7,6 +7,7 @@
 package td_test
 
 import (
+	"reflect"
 	"regexp"
 	"strings"
 	"sync"
@@ -546,6 +547,572 @@ func TestIgnoreUnexported(tt *testing.T) {
 		"IgnoreUnexported expects type int be a struct, not a int (@0)")
 }
 
+func TestEquateEmpty(tt *testing.T) {
+	ttt := test.NewTestingTB(tt.Name())
+
+	type Meta struct {
+		Tags []string
+	}
+	type Record struct {
+		Names  []string
+		Counts map[string]int
+		Meta   *Meta
+		Items  []Meta
+	}
+	got := Record{
+		Items: []Meta{{}},
+	}
+	expected := Record{
+		Names:  []string{},
+		Counts: map[string]int{},
+		Meta:   &Meta{Tags: []string{}},
+		Items:  []Meta{{Tags: []string{}}},
+	}
+
+	// Using default config
+	t := td.NewT(ttt)
+	test.IsFalse(tt, t.Cmp(got, expected))
+
+	// EquateEmpty, pointers not concerned
+	t = td.NewT(ttt).EquateEmpty() // enable globally
+	test.IsFalse(tt, t.Cmp(got, expected))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), "DATA.Meta: values differ"),
+		ttt.LastMessage())
+	noMeta := expected
+	noMeta.Meta = nil
+	test.IsTrue(tt, t.Cmp(got, noMeta))
+	test.IsTrue(tt, t.Cmp(got, td.Struct(noMeta, nil)))
+	test.IsTrue(tt, t.Cmp([]int(nil), []int{}))
+	test.IsTrue(tt, t.Cmp(map[int]bool{}, map[int]bool(nil)))
+	test.IsFalse(tt, t.Cmp([]int(nil), []int{1}))
+
+	t = td.NewT(ttt).EquateEmpty(true) // enable globally
+	test.IsTrue(tt, t.Cmp([]int(nil), []int{}))
+
+	t = td.NewT(ttt).EquateEmpty(false) // disable globally
+	test.IsFalse(tt, t.Cmp([]int(nil), []int{}))
+
+	// Pointers have to be listed
+	t = td.NewT(ttt).EquateEmpty((*Meta)(nil)).EquateEmpty()
+	test.IsTrue(tt, t.Cmp(got, expected))
+	test.IsTrue(tt, t.Cmp(expected, got))
+
+	// Only some types
+	t = td.NewT(ttt).EquateEmpty([]string{}, reflect.TypeOf(map[string]int{}))
+	test.IsFalse(tt, t.Cmp(got, expected))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), "DATA.Meta: values differ"),
+		ttt.LastMessage())
+	test.IsFalse(tt, t.Cmp([]int(nil), []int{}))
+
+	t = t.EquateEmpty((*Meta)(nil))
+	test.IsTrue(tt, t.Cmp(got, expected))
+
+	t = t.EquateEmpty().EquateEmpty(false) // enable then disable globally
+	test.IsTrue(tt, t.Cmp(got, expected))
+
+	test.EqualStr(tt,
+		ttt.CatchFatal(func() { td.NewT(ttt).EquateEmpty(42) }),
+		"EquateEmpty expects type int be a slice, a map or a pointer, not a int (@0)")
+}
+
+func TestIgnoreSliceOrder(tt *testing.T) {
+	ttt := test.NewTestingTB(tt.Name())
+
+	type Item struct {
+		ID   int
+		Tags []string
+	}
+	type DTO struct {
+		Items []Item
+		Roles []string
+		IDs   []int
+	}
+	got := DTO{
+		Items: []Item{{ID: 2, Tags: []string{"b", "a"}}, {ID: 1}},
+		Roles: []string{"user", "admin"},
+		IDs:   []int{3, 2, 1},
+	}
+	expected := DTO{
+		Items: []Item{{ID: 1}, {ID: 2, Tags: []string{"a", "b"}}},
+		Roles: []string{"admin", "user"},
+		IDs:   []int{1, 2, 3},
+	}
+
+	// Using default config
+	t := td.NewT(ttt)
+	test.IsFalse(tt, t.Cmp(got, expected))
+
+	// Globally
+	t = td.NewT(ttt).IgnoreSliceOrder()
+	test.IsTrue(tt, t.Cmp(got, expected))
+	test.IsTrue(tt, td.NewT(ttt).IgnoreSliceOrder(true).Cmp(got, expected))
+	test.IsFalse(tt, td.NewT(ttt).IgnoreSliceOrder(false).Cmp(got, expected))
+
+	// Only some types
+	t = td.NewT(ttt).IgnoreSliceOrder(Item{}, reflect.TypeOf(""))
+	test.IsFalse(tt, t.Cmp(got, expected))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), "DATA.IDs[0]: values differ"),
+		ttt.LastMessage())
+
+	// Types and paths
+	t = t.IgnoreSliceOrder("DATA.IDs")
+	test.IsTrue(tt, t.Cmp(got, expected))
+
+	// Only paths
+	t = td.NewT(ttt).IgnoreSliceOrder("DATA.Items", "**.Tags", "DATA.Roles", "DATA.IDs")
+	test.IsTrue(tt, t.Cmp(got, expected))
+	t = td.NewT(ttt).IgnoreSliceOrder("DATA.Items", "DATA.Roles", "DATA.IDs")
+	test.IsFalse(tt, t.Cmp(got, expected))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), "comparing DATA.Items as a Bag\n"),
+		ttt.LastMessage())
+
+	// Still a Bag: duplicates count
+	t = td.NewT(ttt).IgnoreSliceOrder()
+	test.IsFalse(tt, t.Cmp([]int{1, 1, 2}, []int{1, 2, 2}))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), `comparing DATA as a Bag
+	Missing item: (2)
+	  Extra item: (1)`), ttt.LastMessage())
+
+	// Paths of unordered items contain their got index
+	got.Items = []Item{{ID: 10, Tags: []string{"b"}}, {ID: 20, Tags: []string{"a"}}}
+	expected.Items = []Item{{Tags: []string{"a"}}, {Tags: []string{"b"}}}
+	t = td.NewT(ttt).IgnoreSliceOrder(Item{}).IgnorePaths("DATA.Items[*].ID")
+	test.IsTrue(tt, t.Cmp(DTO{Items: got.Items}, DTO{Items: expected.Items}))
+	test.IsFalse(tt, t.Cmp(got.Items, expected.Items))
+	test.IsTrue(tt, t.IgnorePaths("DATA[*].ID").Cmp(got.Items, expected.Items))
+	t = td.NewT(ttt).IgnoreSliceOrder(Item{}, "DATA[1].Tags").IgnorePaths("DATA[*].ID")
+	test.IsTrue(tt, t.Cmp(
+		[]Item{{Tags: []string{"a"}}, {Tags: []string{"c", "b"}}},
+		[]Item{{Tags: []string{"b", "c"}}, {Tags: []string{"a"}}}))
+
+	// Enable then disable globally
+	t = td.NewT(ttt).IgnoreSliceOrder(0).IgnoreSliceOrder().IgnoreSliceOrder(false)
+	test.IsTrue(tt, t.Cmp([]int{2, 1}, []int{1, 2}))
+	test.IsFalse(tt, t.Cmp([]string{"b", "a"}, []string{"a", "b"}))
+
+	test.EqualStr(tt,
+		ttt.CatchFatal(func() { td.NewT(ttt).IgnoreSliceOrder(nil) }),
+		"IgnoreSliceOrder expects a type, not nil (@0)")
+}
+
+func TestUseStructTags(tt *testing.T) {
+	ttt := test.NewTestingTB(tt.Name())
+
+	type MyInt int
+	type Meta struct {
+		Labels []string
+	}
+	type Record struct {
+		ID        int64     `testdeep:"ignore"`
+		Count     MyInt     `testdeep:"lax"`
+		Date      time.Time `testdeep:"useEqual"`
+		Tags      []string  `testdeep:"unordered"`
+		Score     float64   `testdeep:"approx=1e-3"`
+		CreatedAt time.Time `testdeep:"trunc=1s"`
+		Meta      *Meta     `testdeep:"emptyEqNil"`
+		Names     []string  `json:"names" testdeep:"emptyEqNil"`
+	}
+
+	now := time.Date(2022, 9, 1, 12, 0, 0, 100, time.UTC)
+	got := Record{
+		ID:        42,
+		Count:     3,
+		Date:      now,
+		Tags:      []string{"b", "a"},
+		Score:     1.0001,
+		CreatedAt: now,
+		Meta:      &Meta{Labels: []string{}},
+	}
+	expected := Record{
+		Count:     3,
+		Date:      now.In(time.FixedZone("X", 3600)),
+		Tags:      []string{"a", "b"},
+		Score:     1,
+		CreatedAt: now.Add(time.Millisecond),
+		Names:     []string{},
+	}
+
+	// Tags ignored by default
+	t := td.NewT(ttt)
+	test.IsFalse(tt, t.Cmp(got, expected))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), "DATA.ID: values differ"),
+		ttt.LastMessage())
+
+	t = td.NewT(ttt).UseStructTags()
+	test.IsTrue(tt, t.Cmp(got, expected))
+	test.IsTrue(tt, t.Cmp(got, td.Struct(expected, nil)))
+	test.IsTrue(tt, t.Cmp(&got, td.Struct(&Record{}, td.StructFields{
+		"ID":    int64(1234), // ignored
+		"Count": td.Lax(3),
+		"Date":  expected.Date,
+		"Tags":  td.Bag("a", "b"),
+		"Score": 1.0,
+		"Names": []string{},
+		"Meta":  td.Ptr(Meta{}),
+		// CreatedAt: trunc ineffective as expected is an operator
+		"CreatedAt": td.Between(now, now.Add(time.Second)),
+	})))
+
+	test.IsFalse(tt, t.UseStructTags(false).Cmp(got, expected))
+
+	// Errors
+	bad := expected
+	bad.CreatedAt = now.Add(time.Second)
+	test.IsFalse(tt, t.Cmp(got, bad))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(),
+		"DATA.CreatedAt: values differ (truncated to 1s)"), ttt.LastMessage())
+
+	bad = expected
+	bad.Score = 1.1
+	test.IsFalse(tt, t.Cmp(got, bad))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(),
+		"DATA.Score: values differ (delta 0.0999 out of tolerance)"), ttt.LastMessage())
+
+	bad = expected
+	bad.Tags = []string{"a", "c"}
+	test.IsFalse(tt, t.Cmp(got, bad))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(),
+		"comparing DATA.Tags as a Bag"), ttt.LastMessage())
+
+	type Bad struct {
+		Field int `testdeep:"approx"`
+	}
+	test.IsFalse(tt, t.Cmp(Bad{}, Bad{}))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), `DATA.Field: bad testdeep struct tag
+	option approx expects a positive float, not ""`), ttt.LastMessage())
+
+	// Bad tag reported as soon as its struct is compared, whatever
+	// the fields values
+	type BadLast struct {
+		Num   int
+		Field int `testdeep:"trunc"`
+	}
+	test.IsFalse(tt, t.Cmp(BadLast{Num: 1}, BadLast{Num: 2}))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), `DATA.Field: bad testdeep struct tag
+	option trunc expects a positive duration, not ""`), ttt.LastMessage())
+	test.IsFalse(tt, t.Cmp(BadLast{}, td.Struct(BadLast{}, nil)))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), `DATA.Field: bad testdeep struct tag`),
+		ttt.LastMessage())
+}
+
+func TestSourceLines(tt *testing.T) {
+	ttt := test.NewTestingTB(tt.Name())
+
+	t := td.NewT(ttt, td.ContextConfig{SourceLines: -1})
+	test.IsFalse(tt, t.Cmp([]int{1, 2}, td.ArrayEach(td.Lt(2))))
+	test.IsFalse(tt, strings.Contains(ttt.LastMessage(), "td/t_struct_test.go:\n"))
+
+	t = td.NewT(ttt, td.ContextConfig{SourceLines: 1})
+	test.IsFalse(tt, t.Cmp([]int{1, 2}, td.ArrayEach(td.Lt(2))))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), `
+	td/t_struct_test.go:
+	`), ttt.LastMessage())
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), ` | 	test.IsFalse(tt, t.Cmp([]int{1, 2}, td.ArrayEach(td.Lt(2))))
+	    | 	                                                    ^`), ttt.LastMessage())
+}
+
+func TestPathStyle(tt *testing.T) {
+	type Item struct {
+		ID int `json:"id"`
+	}
+	type Body struct {
+		Items []Item `json:"items"`
+		Meta  map[string]int
+		Name  string `json:"name"`
+	}
+	got := Body{Items: []Item{{ID: 1}, {ID: 2}}, Meta: map[string]int{"a/b": 1}, Name: "foo"}
+	expected := Body{Items: []Item{{ID: 1}, {ID: 3}}, Meta: map[string]int{"a/b": 2}}
+
+	for _, tc := range []struct {
+		style    td.PathStyle
+		expected []string
+		re       string
+	}{
+		{
+			style:    td.PathStyleGo,
+			expected: []string{"DATA.Items[1].ID: values differ", `DATA.Meta["a/b"]: values differ`},
+			re:       `(DATA.Name =~ ^(\w+)$)[0]: values differ`,
+		},
+		{
+			style:    td.PathStyleJSONPointer,
+			expected: []string{"/items/1/id: values differ", "/Meta/a~1b: values differ"},
+			re:       `(/name =~ ^(\w+)$)/0: values differ`,
+		},
+		{
+			style:    td.PathStyleJSONPath,
+			expected: []string{"$.items[1].id: values differ", "$.Meta['a/b']: values differ"},
+			re:       `($.name =~ ^(\w+)$)[0]: values differ`,
+		},
+	} {
+		ttt := test.NewTestingTB(tt.Name())
+		t := td.NewT(ttt).PathStyle(tc.style)
+		test.IsFalse(tt, t.Cmp(got, expected))
+		for _, exp := range tc.expected {
+			test.IsTrue(tt, strings.Contains(ttt.LastMessage(), exp),
+				"%q not found in %q", exp, ttt.LastMessage())
+		}
+
+		// Struct operator too
+		ttt = test.NewTestingTB(tt.Name())
+		t = td.NewT(ttt, td.ContextConfig{PathStyle: tc.style})
+		test.IsFalse(tt, t.Cmp(got, td.Struct(Body{}, td.StructFields{
+			"Items": expected.Items,
+			"Meta":  td.Ignore(),
+		})))
+		test.IsTrue(tt, strings.Contains(ttt.LastMessage(), tc.expected[0]),
+			"%q not found in %q", tc.expected[0], ttt.LastMessage())
+
+		// Re captures too
+		ttt = test.NewTestingTB(tt.Name())
+		t = td.NewT(ttt).PathStyle(tc.style)
+		test.IsFalse(tt, t.Cmp(got, td.Struct(Body{}, td.StructFields{
+			"Items": td.ArrayEach(td.Ignore()),
+			"Meta":  td.Ignore(),
+			"Name":  td.Re(`^(\w+)$`, []string{"bar"}),
+		})))
+		test.IsTrue(tt, strings.Contains(ttt.LastMessage(), tc.re),
+			"%q not found in %q", tc.re, ttt.LastMessage())
+	}
+}
+
+func TestGroupErrors(tt *testing.T) {
+	type Item struct {
+		Status string
+	}
+	items := make([]Item, 500)
+	for i := range items {
+		switch {
+		case i < 412:
+			items[i].Status = "pending"
+		default:
+			items[i].Status = "done"
+		}
+	}
+
+	ttt := test.NewTestingTB(tt.Name())
+	t := td.NewT(ttt).GroupErrors()
+	test.IsFalse(tt, t.Cmp(items, td.ArrayEach(Item{Status: "done"})))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), `DATA[*].Status: values differ
+	   items: 412 of 500
+	 indexes: [0], [1], [2], [3], [4], …
+	     got: "pending" (×412)
+	expected: "done" (×412)
+[under operator ArrayEach at t_struct_test.go:`), ttt.LastMessage())
+
+	ttt = test.NewTestingTB(tt.Name())
+	t = td.NewT(ttt).GroupErrors().GroupErrors(false)
+	test.IsFalse(tt, t.Cmp(items, td.ArrayEach(Item{Status: "done"})))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), "DATA[0].Status: values differ"),
+		ttt.LastMessage())
+}
+
+func TestIgnorePaths(tt *testing.T) {
+	type Item struct {
+		ID   int
+		Name string
+	}
+	type Record struct {
+		CreatedAt time.Time
+		Items     []*Item
+		Meta      map[string]any
+	}
+
+	got := Record{
+		CreatedAt: time.Now(),
+		Items:     []*Item{{ID: 12, Name: "foo"}, {ID: 13, Name: "bar"}},
+		Meta:      map[string]any{"updated_at": "now", "sub": map[string]any{"updated_at": 1}},
+	}
+	expected := Record{
+		Items: []*Item{{Name: "foo"}, {Name: "bar"}},
+		Meta:  map[string]any{"updated_at": "then", "sub": map[string]any{"updated_at": 1}},
+	}
+
+	t := td.NewT(tt).IgnorePaths("DATA.CreatedAt", "DATA.Items[*].ID", "**.updated_at")
+	t.Cmp(got, expected)
+	t.Cmp(got, td.Struct(expected, nil))
+	t.Cmp(got, td.Struct(Record{}, td.StructFields{
+		"Items": []*Item{{Name: "foo"}, {Name: "bar"}},
+		"Meta":  td.Map(map[string]any{"updated_at": "then", "sub": td.Ignore()}, nil),
+	}))
+
+	ttt := test.NewTestingTB(tt.Name())
+	t = td.NewT(ttt).IgnorePaths("DATA.CreatedAt", "DATA.Items[*].ID")
+	test.IsFalse(tt, t.Cmp(got, expected))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), `DATA.Meta["updated_at"]: values differ`),
+		ttt.LastMessage())
+
+	// Patterns are added, original t is not altered
+	t2 := t.IgnorePaths("**.updated_at")
+	test.EqualInt(tt, len(t.Config.IgnorePaths), 2)
+	test.EqualInt(tt, len(t2.Config.IgnorePaths), 3)
+	test.IsTrue(tt, t2.Cmp(got, expected))
+
+	test.EqualInt(tt, len(t2.IgnorePaths().Config.IgnorePaths), 0)
+
+	// Root name is taken into account
+	t = td.NewT(tt).RootName("REC").IgnorePaths("REC.CreatedAt", "REC.Items.**", "REC.Meta")
+	t.Cmp(got, Record{})
+
+	test.IsTrue(tt, td.NewT(tt).IgnorePaths("DATA").Cmp(1, 2))
+
+	// EqDeeply honors DefaultContextConfig patterns too
+	defer func(ignorePaths, ignoreSliceOrderPaths []string) {
+		td.DefaultContextConfig.IgnorePaths = ignorePaths
+		td.DefaultContextConfig.IgnoreSliceOrderPaths = ignoreSliceOrderPaths
+	}(td.DefaultContextConfig.IgnorePaths, td.DefaultContextConfig.IgnoreSliceOrderPaths)
+
+	test.IsFalse(tt, td.EqDeeply(got, expected))
+	td.DefaultContextConfig.IgnorePaths = []string{"DATA.CreatedAt", "DATA.Items[*].ID", "**.updated_at"}
+	test.IsTrue(tt, td.EqDeeply(got, expected))
+
+	test.IsFalse(tt, td.EqDeeply([]int{1, 2, 3}, []int{3, 2, 1}))
+	td.DefaultContextConfig.IgnoreSliceOrderPaths = []string{"DATA"}
+	test.IsTrue(tt, td.EqDeeply([]int{1, 2, 3}, []int{3, 2, 1}))
+}
+
+func TestFloatTolerance(tt *testing.T) {
+	type Result struct {
+		Energy float64
+		Ratio  float32
+		Wave   complex128
+		Values map[string]any
+	}
+	a, b := 0.1, 0.2
+	got := Result{
+		Energy: a + b,
+		Ratio:  1.0001,
+		Wave:   complex(1.0001, -2),
+		Values: map[string]any{"x": []float64{1.00001, 2}},
+	}
+	expected := Result{
+		Energy: 0.3,
+		Ratio:  1,
+		Wave:   complex(1, -2),
+		Values: map[string]any{"x": []float64{1, 2}},
+	}
+
+	ttt := test.NewTestingTB(tt.Name())
+	t := td.NewT(ttt)
+	test.IsFalse(tt, t.Cmp(got, expected))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), "DATA.Energy: values differ\n"),
+		ttt.LastMessage())
+
+	test.IsTrue(tt, t.FloatTolerance(0, 1e-3, 0).Cmp(got, expected))
+	test.IsTrue(tt, t.FloatTolerance(1e-3, 0, 0).Cmp(got, expected))
+
+	// Operators are not affected
+	test.IsFalse(tt, t.FloatTolerance(1e-3, 0, 0).Cmp(got, td.Struct(Result{}, td.StructFields{
+		"Energy": td.N(0.3, 0.0),
+		"Ratio":  float32(1),
+		"Wave":   complex(1, -2),
+		"Values": map[string]any{"x": []float64{1, 2}},
+	})))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(), "DATA.Energy: values differ\n"),
+		ttt.LastMessage())
+
+	// Only ULPs: Energy is OK, not the others
+	test.IsFalse(tt, t.FloatTolerance(0, 0, 2).Cmp(got, expected))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(),
+		"DATA.Ratio: values differ (delta 0.0001 out of tolerance)"),
+		ttt.LastMessage())
+
+	test.IsFalse(tt, t.FloatTolerance(0, 1e-5, 0).Cmp(got.Wave, expected.Wave))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(),
+		"DATA: values differ (delta 0.0001 out of tolerance)"),
+		ttt.LastMessage())
+
+	test.IsTrue(tt, t.FloatTolerance(1e-3, 0, 0).FloatTolerance(0, 0, 0).
+		Config.FloatTolerance == td.FloatTolerance{})
+}
+
+func TestTimeTolerance(tt *testing.T) {
+	type Record struct {
+		CreatedAt time.Time
+		Events    []time.Time
+	}
+	now := time.Now()
+	got := Record{
+		CreatedAt: now,
+		Events:    []time.Time{now.Add(-time.Second), now},
+	}
+	expected := Record{
+		CreatedAt: now.Add(500 * time.Millisecond).UTC(),
+		Events:    []time.Time{now.Add(-1500 * time.Millisecond), now.Round(0)},
+	}
+
+	ttt := test.NewTestingTB(tt.Name())
+	t := td.NewT(ttt)
+	test.IsFalse(tt, t.Cmp(got, expected))
+
+	test.IsTrue(tt, t.TimeTolerance(time.Second).Cmp(got, expected))
+
+	test.IsFalse(tt, t.TimeTolerance(100*time.Millisecond).Cmp(got, expected))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(),
+		"DATA.CreatedAt: values differ (delta 500ms out of tolerance 100ms)"),
+		ttt.LastMessage())
+
+	test.IsTrue(tt, t.TimeTolerance(time.Second).TimeTolerance(0).Config.TimeTolerance == 0)
+}
+
+func TestCheckAliasing(tt *testing.T) {
+	type Node struct {
+		Name string
+		Next *Node
+	}
+
+	ttt := test.NewTestingTB(tt.Name())
+	t := td.NewT(ttt)
+
+	shared := &Node{Name: "shared"}
+	expected := [2]*Node{shared, shared}
+
+	got := [2]*Node{{Name: "shared"}, {Name: "shared"}}
+	test.IsTrue(tt, t.Cmp(got, expected))
+
+	test.IsFalse(tt, t.CheckAliasing().Cmp(got, expected))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(),
+		"DATA[1]: pointer aliasing differs\n\texpected pointer already paired with another got one at DATA[0]"),
+		ttt.LastMessage())
+
+	// And vice versa
+	test.IsFalse(tt, t.CheckAliasing().Cmp(expected, got))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(),
+		"DATA[1]: pointer aliasing differs\n\tgot pointer already paired with another expected one at DATA[0]"),
+		ttt.LastMessage())
+
+	got[1] = got[0]
+	test.IsTrue(tt, t.CheckAliasing().Cmp(got, expected))
+	test.IsTrue(tt, t.CheckAliasing(true).CheckAliasing(false).Cmp(expected, [2]*Node{{Name: "shared"}, {Name: "shared"}}))
+
+	// Cycles
+	newCycle := func(n int) *Node {
+		first := &Node{Name: "node"}
+		last := first
+		for i := 1; i < n; i++ {
+			last.Next = &Node{Name: "node"}
+			last = last.Next
+		}
+		last.Next = first
+		return first
+	}
+	test.IsTrue(tt, t.Cmp(newCycle(1), newCycle(2)))
+	test.IsTrue(tt, t.CheckAliasing().Cmp(newCycle(2), newCycle(2)))
+	test.IsFalse(tt, t.CheckAliasing().Cmp(newCycle(1), newCycle(2)))
+	test.IsTrue(tt, strings.Contains(ttt.LastMessage(),
+		"DATA.Next: pointer aliasing differs\n\tgot pointer already paired with another expected one at DATA"),
+		ttt.LastMessage())
+
+	// Failed candidates do not impact the result
+	a, b := &Node{Name: "a"}, &Node{Name: "b"}
+	shared.Name = "a"
+	test.IsTrue(tt, t.CheckAliasing().Cmp(
+		[]*Node{a, b, a},
+		td.Bag(&Node{Name: "b"}, shared, shared)))
+	test.IsFalse(tt, t.CheckAliasing().Cmp(
+		[]*Node{a, b, {Name: "a"}},
+		td.Bag(&Node{Name: "b"}, shared, shared)))
+}
+
 func TestLogTrace(tt *testing.T) {
 	ttt := test.NewTestingTB(tt.Name())
 
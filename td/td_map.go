@@ -311,10 +311,20 @@ func (m *tdMap) match(ctx ctxerr.Context, got reflect.Value) (err *ctxerr.Error)
 		return ctx.CollectError(err)
 	}
 
+	// Normalize keys if a normalizer is recorded for their type
+	got, err = normalizeMapKeys(ctx, got, "got")
+	if err != nil {
+		return ctx.CollectError(err)
+	}
+	entries, err := normalizeMapEntries(ctx, m.expectedEntries, got.Type().Key())
+	if err != nil {
+		return ctx.CollectError(err)
+	}
+
 	var notFoundKeys []reflect.Value
 	foundKeys := map[any]bool{}
 
-	for _, entryInfo := range m.expectedEntries {
+	for _, entryInfo := range entries {
 		gotValue := got.MapIndex(entryInfo.key)
 		if !gotValue.IsValid() {
 			notFoundKeys = append(notFoundKeys, entryInfo.key)
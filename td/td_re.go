@@ -194,7 +194,7 @@ func (r *tdRe) matchStringCaptures(ctx ctxerr.Context, got string, result [][]st
 
 func (r *tdRe) matchCaptures(ctx ctxerr.Context, captures any) (err *ctxerr.Error) {
 	return deepValueEqual(
-		ctx.ResetPath("("+ctx.Path.String()+" =~ "+r.String()+")"),
+		ctx.ResetExprPath("("+ctx.Path.StringStyle(ctx.PathStyle)+" =~ "+r.String()+")"),
 		reflect.ValueOf(captures), r.captures)
 }
 
@@ -59,106 +59,117 @@ func (s *tdSetBase) Match(ctx ctxerr.Context, got reflect.Value) *ctxerr.Error {
 		fallthrough
 
 	case reflect.Array, reflect.Slice:
-		var (
-			gotLen = got.Len()
+		return s.matchItems(ctx, got, s.GetLocation().Func)
+	}
 
-			foundItems    []reflect.Value
-			missingItems  []reflect.Value
-			foundGotIdxes = map[int]bool{}
-		)
+	if ctx.BooleanError {
+		return ctxerr.BooleanError
+	}
+	return ctx.CollectError(ctxerr.BadKind(got, "slice OR array OR *slice OR *array"))
+}
 
-		for _, expected := range s.expectedItems {
-			found := false
+// matchItems matches got slice or array items against s expected
+// items. name is the name of the operator, used in error message.
+func (s *tdSetBase) matchItems(ctx ctxerr.Context, got reflect.Value, name string) *ctxerr.Error {
+	var (
+		gotLen = got.Len()
 
-			for idx := 0; len(foundGotIdxes) < gotLen && idx < gotLen; idx++ {
-				if foundGotIdxes[idx] {
-					continue
-				}
+		foundItems    []reflect.Value
+		missingItems  []reflect.Value
+		foundGotIdxes = map[int]bool{}
+	)
 
-				if deepValueEqualFinalOK(ctx, got.Index(idx), expected) {
-					foundItems = append(foundItems, expected)
+	// Each got item is compared in a boolean context, so it is not
+	// counted as a visited item when grouping errors
+	itemsCtx := ctx
+	itemsCtx.BooleanError = true
 
-					foundGotIdxes[idx] = true
-					found = true
+	for _, expected := range s.expectedItems {
+		found := false
 
-					if !s.ignoreDups {
-						break
-					}
-				}
+		for idx := 0; len(foundGotIdxes) < gotLen && idx < gotLen; idx++ {
+			if foundGotIdxes[idx] {
+				continue
 			}
 
-			if !found {
-				missingItems = append(missingItems, expected)
+			if deepValueEqualFinalOK(itemsCtx.AddArrayIndex(idx), got.Index(idx), expected) {
+				foundItems = append(foundItems, expected)
+
+				foundGotIdxes[idx] = true
+				found = true
+
+				if !s.ignoreDups {
+					break
+				}
 			}
 		}
 
-		res := tdSetResult{
-			Kind: itemsSetResult,
-			Sort: true,
+		if !found {
+			missingItems = append(missingItems, expected)
 		}
+	}
 
-		if s.kind != noneSet {
-			if s.kind != subSet {
-				// In Set* cases with missing items, try a second pass. Perhaps
-				// an already matching got item, matches another expected item?
-				if s.ignoreDups && len(missingItems) > 0 {
-					var newMissingItems []reflect.Value
-
-				nextExpected:
-					for _, expected := range missingItems {
-						for idxGot := range foundGotIdxes {
-							if deepValueEqualFinalOK(ctx, got.Index(idxGot), expected) {
-								continue nextExpected
-							}
-						}
+	res := tdSetResult{
+		Kind: itemsSetResult,
+		Sort: true,
+	}
 
-						newMissingItems = append(newMissingItems, expected)
+	if s.kind != noneSet {
+		if s.kind != subSet {
+			// In Set* cases with missing items, try a second pass. Perhaps
+			// an already matching got item, matches another expected item?
+			if s.ignoreDups && len(missingItems) > 0 {
+				var newMissingItems []reflect.Value
+
+			nextExpected:
+				for _, expected := range missingItems {
+					for idxGot := range foundGotIdxes {
+						if deepValueEqualFinalOK(itemsCtx.AddArrayIndex(idxGot), got.Index(idxGot), expected) {
+							continue nextExpected
+						}
 					}
 
-					missingItems = newMissingItems
+					newMissingItems = append(newMissingItems, expected)
 				}
 
-				if len(missingItems) > 0 {
-					if ctx.BooleanError {
-						return ctxerr.BooleanError
-					}
-					res.Missing = missingItems
-				}
+				missingItems = newMissingItems
 			}
 
-			if len(foundGotIdxes) < gotLen && s.kind != superSet {
+			if len(missingItems) > 0 {
 				if ctx.BooleanError {
 					return ctxerr.BooleanError
 				}
-				notFoundRemain := gotLen - len(foundGotIdxes)
-				res.Extra = make([]reflect.Value, 0, notFoundRemain)
-				for idx := 0; notFoundRemain > 0; idx++ {
-					if !foundGotIdxes[idx] {
-						res.Extra = append(res.Extra, got.Index(idx))
-						notFoundRemain--
-					}
-				}
+				res.Missing = missingItems
 			}
-		} else if len(foundItems) > 0 {
+		}
+
+		if len(foundGotIdxes) < gotLen && s.kind != superSet {
 			if ctx.BooleanError {
 				return ctxerr.BooleanError
 			}
-			res.Extra = foundItems
+			notFoundRemain := gotLen - len(foundGotIdxes)
+			res.Extra = make([]reflect.Value, 0, notFoundRemain)
+			for idx := 0; notFoundRemain > 0; idx++ {
+				if !foundGotIdxes[idx] {
+					res.Extra = append(res.Extra, got.Index(idx))
+					notFoundRemain--
+				}
+			}
 		}
-
-		if res.IsEmpty() {
-			return nil
+	} else if len(foundItems) > 0 {
+		if ctx.BooleanError {
+			return ctxerr.BooleanError
 		}
-		return ctx.CollectError(&ctxerr.Error{
-			Message: "comparing %% as a " + s.GetLocation().Func,
-			Summary: res.Summary(),
-		})
+		res.Extra = foundItems
 	}
 
-	if ctx.BooleanError {
-		return ctxerr.BooleanError
+	if res.IsEmpty() {
+		return nil
 	}
-	return ctx.CollectError(ctxerr.BadKind(got, "slice OR array OR *slice OR *array"))
+	return ctx.CollectError(&ctxerr.Error{
+		Message: "comparing %% as a " + name,
+		Summary: res.Summary(),
+	})
 }
 
 func (s *tdSetBase) String() string {
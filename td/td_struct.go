@@ -707,11 +707,25 @@ func (s *tdStruct) Match(ctx ctxerr.Context, got reflect.Value) (err *ctxerr.Err
 
 	ignoreUnexported := ctx.IgnoreUnexported || ctx.Hooks.IgnoreUnexported(got.Type())
 
+	if ctx.UseStructTags {
+		if tags := getStructTags(got.Type()); tags.err != nil {
+			return tags.badTagError(ctx)
+		}
+	}
+
 	for _, fieldInfo := range s.expectedFields {
 		if ignoreUnexported && fieldInfo.unexported {
 			continue
 		}
-		err = deepValueEqual(ctx.AddField(fieldInfo.name),
+		var opts *fieldOptions
+		if ctx.UseStructTags {
+			tags, idx := getFieldTags(got.Type(), fieldInfo.index)
+			if tags.err != nil {
+				return tags.badTagError(ctx)
+			}
+			opts = tags.fields[idx]
+		}
+		err = deepValueEqualField(ctx, got.Type().FieldByIndex(fieldInfo.index), opts,
 			got.FieldByIndex(fieldInfo.index), fieldInfo.expected)
 		if err != nil {
 			return
@@ -0,0 +1,133 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package td
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"reflect"
+	"time"
+
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/dark"
+)
+
+// isFloatKind returns true if k is a float or a complex kind.
+func isFloatKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
+	}
+	return false
+}
+
+// orderedBits64 returns the bits of f as an int64 ordered as floats
+// are, so the difference between two of them is their distance in
+// ULPs. -0 and +0 have the same representation.
+func orderedBits64(f float64) int64 {
+	i := int64(math.Float64bits(f))
+	if i < 0 {
+		return math.MinInt64 - i
+	}
+	return i
+}
+
+func orderedBits32(f float32) int64 {
+	i := int32(math.Float32bits(f))
+	if i < 0 {
+		return int64(math.MinInt32 - i)
+	}
+	return int64(i)
+}
+
+// ulpsDistance returns the number of representable floats between a
+// and b. If is32 is true, a and b are considered as float32.
+func ulpsDistance(a, b float64, is32 bool) uint64 {
+	var ia, ib int64
+	if is32 {
+		ia, ib = orderedBits32(float32(a)), orderedBits32(float32(b))
+	} else {
+		ia, ib = orderedBits64(a), orderedBits64(b)
+	}
+	if ia < ib {
+		ia, ib = ib, ia
+	}
+	return uint64(ia) - uint64(ib)
+}
+
+// floatWithinTolerance returns true if a and b are equal according
+// to tol. NaN never equals anything and infinities are only equal to
+// themselves.
+func floatWithinTolerance(tol ctxerr.FloatTolerance, a, b float64, is32 bool) bool {
+	if a == b {
+		return true
+	}
+	if math.IsNaN(a) || math.IsNaN(b) || math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return false
+	}
+	delta := math.Abs(a - b)
+	return delta <= tol.Absolute ||
+		delta <= tol.Relative*math.Max(math.Abs(a), math.Abs(b)) ||
+		(tol.ULPs > 0 && ulpsDistance(a, b, is32) <= tol.ULPs)
+}
+
+// floatToleranceEqual compares got and expected floats or complex
+// numbers using ctx.FloatTolerance. got and expected have the same
+// type.
+func floatToleranceEqual(ctx ctxerr.Context, got, expected reflect.Value) *ctxerr.Error {
+	var (
+		ok    bool
+		delta float64
+	)
+	switch got.Kind() {
+	case reflect.Float32, reflect.Float64:
+		g, e := got.Float(), expected.Float()
+		ok = floatWithinTolerance(ctx.FloatTolerance, g, e, got.Kind() == reflect.Float32)
+		delta = math.Abs(g - e)
+
+	default: // reflect.Complex64, reflect.Complex128
+		g, e := got.Complex(), expected.Complex()
+		is32 := got.Kind() == reflect.Complex64
+		ok = floatWithinTolerance(ctx.FloatTolerance, real(g), real(e), is32) &&
+			floatWithinTolerance(ctx.FloatTolerance, imag(g), imag(e), is32)
+		delta = cmplx.Abs(g - e)
+	}
+	if ok {
+		return nil
+	}
+	if ctx.BooleanError {
+		return ctxerr.BooleanError
+	}
+	return ctx.CollectError(&ctxerr.Error{
+		Message:  fmt.Sprintf("values differ (delta %.3g out of tolerance)", delta),
+		Got:      got,
+		Expected: expected,
+	})
+}
+
+// timeToleranceEqual compares got and expected time.Time values using
+// ctx.TimeTolerance.
+func timeToleranceEqual(ctx ctxerr.Context, got, expected reflect.Value) *ctxerr.Error {
+	delta := dark.MustGetInterface(got).(time.Time).
+		Sub(dark.MustGetInterface(expected).(time.Time))
+	if delta < 0 {
+		delta = -delta
+	}
+	if delta <= ctx.TimeTolerance {
+		return nil
+	}
+	if ctx.BooleanError {
+		return ctxerr.BooleanError
+	}
+	return ctx.CollectError(&ctxerr.Error{
+		Message: fmt.Sprintf("values differ (delta %s out of tolerance %s)",
+			delta, ctx.TimeTolerance),
+		Got:      got,
+		Expected: expected,
+	})
+}
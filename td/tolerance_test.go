@@ -0,0 +1,53 @@
+// Copyright (c) 2022, Maxime Soulé
+// All rights reserved.
+//
+// This source code is licensed under the BSD-style license found in the
+// LICENSE file in the root directory of this source tree.
+
+package td
+
+import (
+	"math"
+	"testing"
+
+	"github.com/maxatome/go-testdeep/internal/ctxerr"
+	"github.com/maxatome/go-testdeep/internal/test"
+)
+
+func TestULPsDistance(t *testing.T) {
+	test.EqualInt(t, int(ulpsDistance(1, 1, false)), 0)
+	test.EqualInt(t, int(ulpsDistance(0, math.Copysign(0, -1), false)), 0)
+	test.EqualInt(t, int(ulpsDistance(1, math.Nextafter(1, 2), false)), 1)
+	test.EqualInt(t, int(ulpsDistance(math.Nextafter(1, 2), 1, false)), 1)
+	test.EqualInt(t, int(ulpsDistance(1, math.Nextafter(math.Nextafter(1, 0), 0), false)), 2)
+	test.EqualInt(t, int(ulpsDistance(math.SmallestNonzeroFloat64, -math.SmallestNonzeroFloat64, false)), 2)
+
+	f32 := float64(math.Nextafter32(1, 2))
+	test.EqualInt(t, int(ulpsDistance(1, f32, true)), 1)
+	test.EqualInt(t, int(ulpsDistance(-1, -f32, true)), 1)
+	test.EqualInt(t, int(ulpsDistance(
+		float64(math.SmallestNonzeroFloat32), -float64(math.SmallestNonzeroFloat32), true)), 2)
+}
+
+func TestFloatWithinTolerance(t *testing.T) {
+	abs := ctxerr.FloatTolerance{Absolute: 0.1}
+	test.IsTrue(t, floatWithinTolerance(abs, 1, 1.05, false))
+	test.IsTrue(t, floatWithinTolerance(abs, 1.05, 1, false))
+	test.IsFalse(t, floatWithinTolerance(abs, 1, 1.2, false))
+
+	rel := ctxerr.FloatTolerance{Relative: 0.01}
+	test.IsTrue(t, floatWithinTolerance(rel, 1000, 1009, false))
+	test.IsFalse(t, floatWithinTolerance(rel, 1000, 1011, false))
+	test.IsFalse(t, floatWithinTolerance(rel, 0, 1e-300, false))
+
+	ulps := ctxerr.FloatTolerance{ULPs: 2}
+	test.IsTrue(t, floatWithinTolerance(ulps, 0.1+0.2, 0.3, false))
+	test.IsFalse(t, floatWithinTolerance(ulps, 0.3, 0.30000001, false))
+
+	all := ctxerr.FloatTolerance{Absolute: 1e100, Relative: 1e100, ULPs: math.MaxUint64}
+	test.IsTrue(t, floatWithinTolerance(all, math.Inf(1), math.Inf(1), false))
+	test.IsFalse(t, floatWithinTolerance(all, math.Inf(1), math.Inf(-1), false))
+	test.IsFalse(t, floatWithinTolerance(all, math.Inf(1), 1, false))
+	test.IsFalse(t, floatWithinTolerance(all, math.NaN(), math.NaN(), false))
+	test.IsFalse(t, floatWithinTolerance(all, 1, math.NaN(), false))
+}
@@ -106,6 +106,7 @@ func (t *base) setLocation(callDepth int) {
 		cmpPkg, _ := pkgFunc(cmpLoc.Func)
 		if cmpPkg == pkg {
 			t.location.File = cmpLoc.File
+			t.location.FullPath = cmpLoc.FullPath
 			t.location.Line = cmpLoc.Line
 			t.location.BehindCmp = true
 		}